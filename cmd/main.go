@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -13,14 +14,20 @@ import (
 	"web_service/internal/config"
 	"web_service/internal/delivery/http_handler"
 	"web_service/internal/delivery/kafka_listener"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/auth"
 	"web_service/internal/infrastructure/cache"
 	"web_service/internal/infrastructure/persistent"
 	"web_service/internal/infrastructure/persistent/repositories"
+	"web_service/internal/infrastructure/rates"
+	"web_service/internal/infrastructure/sqlite"
+	"web_service/internal/protocols"
 	"web_service/internal/usecase"
 
 	_ "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -31,31 +38,204 @@ func main() {
 	}
 	cfg := config.Load()
 
-	pool, err := initDatabase(ctx, cfg)
+	db, err := initDatabase(ctx, cfg)
 	if err != nil {
 		log.Fatal("Database initialization failed: ", err)
 	}
-	defer pool.Close()
 
-	getOrderUseCase, saveOrderUseCase := initUseCases(pool)
+	caches, err := initOrderCache(ctx, cfg)
+	if err != nil {
+		log.Fatal("Order cache initialization failed: ", err)
+	}
+	defer caches.close()
 
-	err = getOrderUseCase.RestoreCache(ctx)
+	useCases, err := initUseCases(cfg, db, caches)
+	if err != nil {
+		log.Fatal("Use case initialization failed: ", err)
+	}
+
+	snapshotDone, err := restoreCache(ctx, cfg, caches.storage, useCases.getOrder)
 	if err != nil {
 		log.Fatal("Cache restore failed: ", err)
 	}
 
-	kafkaConsumer, err := startKafkaConsumer(cfg, saveOrderUseCase, ctx)
+	kafkaConsumer, err := startKafkaConsumer(ctx, cfg, useCases)
 	if err != nil {
 		log.Fatal("Kafka consumer startup failed: ", err)
 	}
 
-	server := startHTTPServer(cfg, getOrderUseCase)
+	authenticators, err := initAuthenticators(cfg)
+	if err != nil {
+		log.Fatal("Authentication setup failed: ", err)
+	}
 
-	waitForShutdown(server, kafkaConsumer, pool)
+	reporting, err := initReporting(cfg)
+	if err != nil {
+		log.Fatal("Reporting currency setup failed: ", err)
+	}
+
+	server := startHTTPServer(ctx, cfg, authenticators, reporting, db.loadProbe, caches,
+		useCases.getOrder, useCases.saveOrder)
+
+	waitForShutdown(server, kafkaConsumer, db.close)
+
+	// Останавливаем периодические снимки и дожидаемся записи финального
+	cancel()
+	<-snapshotDone
 }
 
-func initDatabase(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
-	dbConfig, err := pgxpool.ParseConfig(cfg.PostgresDSN)
+// restoreCache прогревает кеш. Если настроен файл снимка и кеш живёт в памяти процесса, сначала
+// загружается снимок, а из БД догружаются только более новые заказы. Возвращаемый канал закрывается,
+// когда после отмены ctx записан последний снимок.
+func restoreCache(ctx context.Context, cfg *config.Config, orderStorage protocols.OrderStorageInterface,
+	getOrderUseCase *usecase.GetOrderUseCase) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	// Redis общий для всех реплик: полный прогрев при старте каждой из них лишь повторял бы одну и ту же работу
+	// и нагружал БД. Общий кеш наполняется промахами и сохранениями, а при необходимости прогревается через admin API.
+	if cfg.CacheBackend == "redis" || cfg.CacheBackend == "tiered" {
+		log.Printf("Skipping cache warm-up for shared %s cache backend", cfg.CacheBackend)
+		close(done)
+		return done, nil
+	}
+	iterable, ok := orderStorage.(protocols.IterableOrderStorageInterface)
+	if cfg.CacheSnapshotPath == "" || !ok {
+		close(done)
+		return done, getOrderUseCase.RestoreCache(ctx)
+	}
+
+	snapshotter := cache.NewSnapshotter(iterable, cfg.CacheSnapshotPath)
+	snapshot, err := snapshotter.Load()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Ignoring cache snapshot %s: %v", cfg.CacheSnapshotPath, err)
+		}
+		err = getOrderUseCase.RestoreCache(ctx)
+	} else {
+		log.Printf("Loaded %d orders from cache snapshot, watermark %s",
+			len(snapshot.Orders), snapshot.Watermark.Format(time.RFC3339))
+		orderStorage.SaveAll(snapshot.Orders)
+		// updated_at ставит транзакция до коммита, а реплики сервиса пишут со своими часами, поэтому берём запас
+		err = getOrderUseCase.RestoreCacheSince(ctx, snapshot.Watermark.Add(-cfg.CacheSnapshotOverlap))
+	}
+	if err != nil {
+		close(done)
+		return done, err
+	}
+
+	go func() {
+		defer close(done)
+		snapshotter.Run(ctx, cfg.CacheSnapshotInterval)
+	}()
+	return done, nil
+}
+
+// database — репозитории и менеджер транзакций БД, выбранной по схеме DSN
+type database struct {
+	orders     protocols.OrderRepoInterface
+	payments   protocols.PaymentRepoInterface
+	operations protocols.PaymentOperationRepoInterface
+	deliveries protocols.DeliveryRepoInterface
+	items      protocols.ItemRepoInterface
+	statuses   protocols.OrderStatusRepoInterface
+	tx         protocols.TransactionManagerInterface
+	// loadProbe сообщает, что заняты все соединения primary, а readProbe — пулов, куда уходят чтения
+	// (здоровых реплик или primary, если их нет); у встроенной БД их нет
+	loadProbe protocols.LoadProbeInterface
+	readProbe protocols.LoadProbeInterface
+	close     func()
+}
+
+func initDatabase(ctx context.Context, cfg *config.Config) (*database, error) {
+	if sqlite.IsDSN(cfg.DatabaseDSN) {
+		return initSQLite(ctx, cfg)
+	}
+	return initPostgres(ctx, cfg)
+}
+
+func initPostgres(ctx context.Context, cfg *config.Config) (*database, error) {
+	pool, err := newPool(ctx, cfg.DatabaseDSN)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	log.Println("Database connected successfully")
+
+	db, err := initCluster(ctx, cfg, pool)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("read replica initialization failed: %w", err)
+	}
+	return &database{
+		orders:     repositories.NewOrderRepo(db),
+		payments:   repositories.NewPaymentRepo(db),
+		operations: repositories.NewPaymentOperationRepo(db),
+		deliveries: repositories.NewDeliveryRepo(db),
+		items:      repositories.NewItemRepo(db),
+		statuses:   repositories.NewOrderStatusRepo(db),
+		tx:         persistent.NewPgxTransactionManager(db),
+		loadProbe:  persistent.NewPoolLoadProbe(pool),
+		readProbe:  persistent.NewReadLoadProbe(db),
+		close: func() {
+			db.Close()
+			pool.Close()
+		},
+	}, nil
+}
+
+// initSQLite открывает встроенную БД для локальной разработки и тестов; схема создаётся при открытии
+func initSQLite(ctx context.Context, cfg *config.Config) (*database, error) {
+	db, err := sqlite.Open(ctx, cfg.DatabaseDSN)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.PostgresReplicaDSNs) > 0 {
+		log.Println("Read replicas are ignored for the embedded SQLite database")
+	}
+	log.Println("Embedded SQLite database opened successfully")
+	return &database{
+		orders:     sqlite.NewOrderRepo(db),
+		payments:   sqlite.NewPaymentRepo(db),
+		operations: sqlite.NewPaymentOperationRepo(db),
+		deliveries: sqlite.NewDeliveryRepo(db),
+		items:      sqlite.NewItemRepo(db),
+		statuses:   sqlite.NewOrderStatusRepo(db),
+		tx:         sqlite.NewTransactionManager(db),
+		close: func() {
+			if err := db.Close(); err != nil {
+				log.Printf("SQLite close error: %v", err)
+			}
+		},
+	}, nil
+}
+
+// initCluster подключает реплики для чтения. Недоступная при старте реплика не мешает запуску:
+// она просто не попадает в ротацию, пока не ответит на проверку.
+func initCluster(ctx context.Context, cfg *config.Config, primary *pgxpool.Pool) (*persistent.Cluster, error) {
+	replicas := make([]*pgxpool.Pool, 0, len(cfg.PostgresReplicaDSNs))
+	for _, dsn := range cfg.PostgresReplicaDSNs {
+		replica, err := newPool(ctx, dsn)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+	db := persistent.NewCluster(primary, replicas, cfg.ReadYourWritesWindow)
+	if len(replicas) > 0 {
+		db.CheckHealth(ctx)
+		go db.RunHealthChecks(ctx, cfg.ReplicaHealthCheckInterval)
+		log.Printf("Using %d read replicas\n", len(replicas))
+	}
+	return db, nil
+}
+
+func newPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	dbConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -63,59 +243,219 @@ func initDatabase(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error
 	dbConfig.MinConns = 2
 	dbConfig.MaxConnLifetime = time.Hour
 	dbConfig.HealthCheckPeriod = time.Minute
+	return pgxpool.NewWithConfig(ctx, dbConfig)
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, dbConfig)
-	if err != nil {
-		return nil, err
+// orderCache — кеш заказов, кеш их отсутствия и ответы по ключам идемпотентности, выбранные CACHE_BACKEND
+type orderCache struct {
+	storage     protocols.OrderStorageInterface
+	notFound    protocols.NotFoundCacheInterface
+	idempotency protocols.IdempotencyStorageInterface
+	close       func()
+}
+
+// initOrderCache создаёт кеш заказов. С общим кешем в Redis снятие отметок об отсутствии заказа
+// рассылается остальным репликам, иначе они отвечали бы 404 на созданный заказ до NOT_FOUND_CACHE_TTL.
+func initOrderCache(ctx context.Context, cfg *config.Config) (*orderCache, error) {
+	notFound := cache.NewLocalNotFoundCache(cfg.NotFoundCacheTTL)
+	switch cfg.CacheBackend {
+	case "local", "lru":
+		local := &orderCache{storage: cache.NewLocalOrderStorage(), notFound: notFound,
+			idempotency: cache.NewLocalIdempotencyStorage(cfg.IdempotencyTTL), close: func() {}}
+		if cfg.CacheBackend == "lru" {
+			local.storage = cache.NewLRUOrderStorage(cfg.L1CacheSize, cfg.L1CacheTTL)
+		}
+		return local, nil
+	case "redis", "tiered":
+		codec, err := cache.CodecByName(cfg.RedisCodec)
+		if err != nil {
+			return nil, err
+		}
+		client, err := connectRedis(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		closeClient := func() {
+			if err := client.Close(); err != nil {
+				log.Printf("Redis client close error: %v", err)
+			}
+		}
+		sharedNotFound := cache.NewSharedNotFoundCache(notFound,
+			cache.NewRedisInvalidationBus(client, cfg.CacheInvalidationChannel+":not-found"))
+		if err := sharedNotFound.Listen(ctx); err != nil {
+			closeClient()
+			return nil, fmt.Errorf("subscribe to not found cache invalidations: %w", err)
+		}
+		shared := &orderCache{notFound: sharedNotFound, close: closeClient,
+			idempotency: cache.NewRedisIdempotencyStorage(client, cfg.RedisKeyPrefix+"idempotency:", cfg.IdempotencyTTL)}
+		redisStorage := cache.NewRedisOrderStorage(client, codec, cfg.RedisKeyPrefix, cfg.RedisTTL)
+		if cfg.CacheBackend == "redis" {
+			shared.storage = redisStorage
+			return shared, nil
+		}
+		tiered := cache.NewTieredOrderStorage(
+			cache.NewLRUOrderStorage(cfg.L1CacheSize, cfg.L1CacheTTL),
+			redisStorage,
+			cache.NewRedisInvalidationBus(client, cfg.CacheInvalidationChannel),
+		)
+		if err := tiered.Listen(ctx); err != nil {
+			closeClient()
+			return nil, fmt.Errorf("subscribe to cache invalidations: %w", err)
+		}
+		shared.storage = tiered
+		return shared, nil
 	}
-	if err := pool.Ping(ctx); err != nil {
+	return nil, fmt.Errorf("unknown cache backend %q, expected local, lru, redis or tiered", cfg.CacheBackend)
+}
+
+func connectRedis(ctx context.Context, cfg *config.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.RedisAddr,
+		Password:     cfg.RedisPassword,
+		DB:           cfg.RedisDB,
+		PoolSize:     cfg.RedisPoolSize,
+		MinIdleConns: cfg.RedisMinIdleConns,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, err
 	}
-	log.Println("Database connected successfully")
-	return pool, nil
+	log.Println("Redis connected successfully")
+	return client, nil
 }
 
-func initUseCases(pool *pgxpool.Pool) (*usecase.GetOrderUseCase, *usecase.SaveOrderUseCase) {
-	transactionManager := persistent.NewPgxTransactionManager(pool)
-	orderRepository := repositories.NewOrderRepo(pool)
-	paymentRepository := repositories.NewPaymentRepo(pool)
-	deliveryRepository := repositories.NewDeliveryRepo(pool)
-	itemRepository := repositories.NewItemRepo(pool)
+// useCases — use case'ы сервиса поверх выбранной БД и кеша
+type useCases struct {
+	getOrder     *usecase.GetOrderUseCase
+	saveOrder    *usecase.SaveOrderUseCase
+	changeStatus *usecase.ChangeOrderStatusUseCase
+	commands     *usecase.OrderCommandUseCase
+}
 
-	orderStorage := cache.NewLocalOrderStorage()
+func initUseCases(cfg *config.Config, db *database, caches *orderCache) (*useCases, error) {
+	cachePolicy, err := usecase.ParseSaveCachePolicy(cfg.SaveCachePolicy)
+	if err != nil {
+		return nil, err
+	}
 
-	getOrderUseCase := usecase.NewGetOrderUseCase(
-		orderRepository, paymentRepository, deliveryRepository, itemRepository, transactionManager, orderStorage)
-	saveOrderUseCase := usecase.NewSaveOrderUseCase(
-		orderRepository, paymentRepository, deliveryRepository, itemRepository, transactionManager)
+	orderStorage, notFoundCache := caches.storage, caches.notFound
 
-	return getOrderUseCase, saveOrderUseCase
+	return &useCases{
+		getOrder: usecase.NewGetOrderUseCase(
+			db.orders, db.payments, db.operations, db.deliveries, db.items, db.statuses, db.tx,
+			orderStorage, notFoundCache, db.readProbe),
+		saveOrder: usecase.NewSaveOrderUseCase(
+			db.orders, db.payments, db.operations, db.deliveries, db.items, db.statuses, db.tx,
+			notFoundCache, orderStorage, cachePolicy),
+		changeStatus: usecase.NewChangeOrderStatusUseCase(db.orders, db.statuses, db.tx, orderStorage),
+		commands: usecase.NewOrderCommandUseCase(
+			db.orders, db.payments, db.operations, db.deliveries, db.items, db.statuses, db.tx,
+			orderStorage),
+	}, nil
 }
 
-func startKafkaConsumer(cfg *config.Config,
-	saveOrderUseCase *usecase.SaveOrderUseCase, ctx context.Context) (*kafka_listener.Consumer, error) {
-	kafkaConsumer, err := kafka_listener.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID, saveOrderUseCase)
+func startKafkaConsumer(ctx context.Context, cfg *config.Config, useCases *useCases) (*kafka_listener.Consumer, error) {
+	// Без брокеров сервис работает только через HTTP, например локально со встроенной БД
+	if cfg.KafkaBrokers == "" {
+		log.Println("KAFKA_BROKERS is not set, Kafka consumer is disabled")
+		return nil, nil
+	}
+	kafkaConsumer, err := kafka_listener.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID, useCases.saveOrder,
+		useCases.changeStatus, useCases.commands)
 	if err != nil {
 		return nil, err
 	}
-	err = kafkaConsumer.Subscribe(cfg.KafkaTopic)
+	err = kafkaConsumer.Subscribe(cfg.KafkaTopic, cfg.KafkaStatusTopic)
 	if err != nil {
 		return nil, err
 	}
 	go func() {
-		log.Printf("Starting Kafka consumer for topic: %s", cfg.KafkaTopic)
+		log.Printf("Starting Kafka consumer for topics: %s %s", cfg.KafkaTopic, cfg.KafkaStatusTopic)
 		kafkaConsumer.Consume(ctx)
 	}()
 
 	return kafkaConsumer, nil
 }
 
-func startHTTPServer(cfg *config.Config, getOrderUseCase *usecase.GetOrderUseCase) *http.Server {
-	handler := http_handler.NewOrderHandler(getOrderUseCase)
+func initAuthenticators(cfg *config.Config) (http_handler.Authenticators, error) {
+	var authenticators http_handler.Authenticators
+	if cfg.AuthAPIKeysFile != "" {
+		apiKeys, err := auth.NewAPIKeyAuthenticatorFromFile(cfg.AuthAPIKeysFile)
+		if err != nil {
+			return authenticators, err
+		}
+		authenticators.APIKey = apiKeys
+	}
+	if cfg.AuthJWKSFile != "" {
+		jwtAuth, err := auth.NewJWTAuthenticatorFromFile(cfg.AuthJWKSFile, cfg.AuthJWTIssuer, cfg.AuthJWTAudience)
+		if err != nil {
+			return authenticators, err
+		}
+		authenticators.Bearer = jwtAuth
+	}
+	if authenticators.APIKey == nil && authenticators.Bearer == nil {
+		return authenticators, errors.New("set AUTH_API_KEYS_FILE and/or AUTH_JWKS_FILE")
+	}
+	return authenticators, nil
+}
+
+// initReporting загружает курсы валют, если задана валюта отчётности; иначе пересчёт выключен
+func initReporting(cfg *config.Config) (*http_handler.ReportingCurrency, error) {
+	if cfg.ReportingCurrency == "" {
+		return nil, nil
+	}
+	currency := domain.Currency(cfg.ReportingCurrency)
+	if !currency.Valid() {
+		return nil, fmt.Errorf("unknown REPORTING_CURRENCY %q", cfg.ReportingCurrency)
+	}
+	if cfg.ExchangeRatesFile == "" {
+		return nil, errors.New("REPORTING_CURRENCY requires EXCHANGE_RATES_FILE")
+	}
+	exchangeRates, err := rates.LoadExchangeRatesFile(cfg.ExchangeRatesFile)
+	if err != nil {
+		return nil, err
+	}
+	// Курс самой валюты отчётности нужен для любого пересчёта, поэтому проверяется при старте
+	if _, err := exchangeRates.Convert(domain.NewMoney(0, exchangeRates.Base()), currency); err != nil {
+		return nil, err
+	}
+	log.Printf("Payment amounts are also reported in %s (rates base %s)", currency, exchangeRates.Base())
+	return &http_handler.ReportingCurrency{Currency: currency, Rates: exchangeRates}, nil
+}
+
+func startHTTPServer(ctx context.Context, cfg *config.Config, authenticators http_handler.Authenticators,
+	reporting *http_handler.ReportingCurrency, loadProbe protocols.LoadProbeInterface, caches *orderCache,
+	getOrderUseCase *usecase.GetOrderUseCase, saveOrderUseCase *usecase.SaveOrderUseCase) *http.Server {
+	orderStorage := caches.storage
+
+	// Redis не хранит готовые тела ответов: их держат только кеши в памяти процесса
+	var representations protocols.RepresentationStorageInterface
+	if cfg.CacheEncodedResponses {
+		representations, _ = orderStorage.(protocols.RepresentationStorageInterface)
+	}
+
+	router := http_handler.NewRouter(
+		http_handler.RouterConfig{
+			Authenticators: authenticators,
+			AllowedOrigins: cfg.CORSAllowedOrigins,
+			RateLimits: map[string]protocols.RouteLimits{
+				http_handler.RouteGetOrder:    cfg.GetOrderRateLimits,
+				http_handler.RouteCreateOrder: cfg.CreateOrderRateLimits,
+				http_handler.RouteCacheAdmin:  cfg.CacheAdminRateLimits,
+			},
+			TrustForwardedFor:     cfg.TrustForwardedFor,
+			MaxConcurrentRequests: cfg.MaxConcurrentRequests,
+			MaxQueueWait:          cfg.MaxQueueWait,
+			LoadProbe:             loadProbe,
+		},
+		http_handler.NewOrderHandler(getOrderUseCase, cfg.OrderCacheControl, representations, reporting),
+		http_handler.NewCreateOrderHandler(saveOrderUseCase, caches.idempotency),
+		http_handler.NewCacheAdminHandler(usecase.NewCacheAdminUseCase(ctx, getOrderUseCase, orderStorage)),
+	)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
-		Handler:      handler,
+		Handler:      router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -131,7 +471,7 @@ func startHTTPServer(cfg *config.Config, getOrderUseCase *usecase.GetOrderUseCas
 	return server
 }
 
-func waitForShutdown(server *http.Server, kafkaConsumer *kafka_listener.Consumer, pool *pgxpool.Pool) {
+func waitForShutdown(server *http.Server, kafkaConsumer *kafka_listener.Consumer, closeDatabase func()) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
@@ -164,8 +504,8 @@ func waitForShutdown(server *http.Server, kafkaConsumer *kafka_listener.Consumer
 
 	wg.Wait()
 
-	if pool != nil {
-		pool.Close()
+	if closeDatabase != nil {
+		closeDatabase()
 		log.Println("Database connection closed")
 	}
 
@@ -9,7 +9,10 @@ CREATE TABLE IF NOT EXISTS orders (
                         shardkey VARCHAR(10) NOT NULL,
                         sm_id INTEGER NOT NULL,
                         date_created TIMESTAMP WITH TIME ZONE NOT NULL,
-                        oof_shard VARCHAR(10) NOT NULL
+                        oof_shard VARCHAR(10) NOT NULL,
+                        status VARCHAR(20) NOT NULL DEFAULT 'created',
+                        version INTEGER NOT NULL DEFAULT 1,
+                        updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
 );
 
 CREATE TABLE IF NOT EXISTS deliveries (
@@ -26,31 +29,53 @@ CREATE TABLE IF NOT EXISTS deliveries (
 
 CREATE TABLE IF NOT EXISTS payments (
                           id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-                          transaction VARCHAR(50) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+                          order_uid VARCHAR(50) NOT NULL UNIQUE REFERENCES orders(order_uid) ON DELETE CASCADE,
+                          transaction VARCHAR(50) NOT NULL,
                           request_id VARCHAR(50),
                           currency VARCHAR(10) NOT NULL,
                           provider VARCHAR(50) NOT NULL,
-                          amount INTEGER NOT NULL,
+                          amount BIGINT NOT NULL,
                           payment_dt BIGINT NOT NULL,
                           bank VARCHAR(50) NOT NULL,
-                          delivery_cost INTEGER NOT NULL,
-                          goods_total INTEGER NOT NULL,
-                          custom_fee INTEGER NOT NULL
+                          delivery_cost BIGINT NOT NULL,
+                          goods_total BIGINT NOT NULL,
+                          custom_fee BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment_operations (
+                          id BIGSERIAL PRIMARY KEY,
+                          order_uid VARCHAR(50) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+                          transaction VARCHAR(50) NOT NULL,
+                          type VARCHAR(10) NOT NULL,
+                          status VARCHAR(10) NOT NULL,
+                          amount BIGINT NOT NULL,
+                          currency VARCHAR(10) NOT NULL,
+                          provider VARCHAR(50) NOT NULL,
+                          performed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                          UNIQUE (order_uid, transaction)
 );
 
 CREATE TABLE IF NOT EXISTS items (
                        rid VARCHAR(50) PRIMARY KEY,
                        track_number VARCHAR(50) NOT NULL REFERENCES orders(track_number),
                        chrt_id INTEGER NOT NULL,
-                       price INTEGER NOT NULL,
+                       price BIGINT NOT NULL,
                        name VARCHAR(100) NOT NULL,
                        sale INTEGER NOT NULL,
                        size VARCHAR(10) NOT NULL,
-                       total_price INTEGER NOT NULL,
+                       total_price BIGINT NOT NULL,
                        nm_id INTEGER NOT NULL,
                        brand VARCHAR(100) NOT NULL,
                        status INTEGER NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS order_status_history (
+                       id BIGSERIAL PRIMARY KEY,
+                       order_uid VARCHAR(50) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+                       status VARCHAR(20) NOT NULL,
+                       changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
 CREATE INDEX idx_orders_track_number ON orders(track_number);
-CREATE INDEX idx_items_track_number ON items(track_number);
\ No newline at end of file
+CREATE INDEX idx_items_track_number ON items(track_number);
+CREATE INDEX idx_order_status_history_order_uid ON order_status_history(order_uid, changed_at);
\ No newline at end of file
@@ -1,21 +1,215 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"web_service/internal/protocols"
+)
 
 type Config struct {
-	KafkaBrokers string
-	KafkaTopic   string
-	KafkaGroupID string
-	PostgresDSN  string
-	HTTPPort     string
+	KafkaBrokers   string
+	KafkaTopic     string
+	KafkaGroupID   string
+	DatabaseDSN    string
+	HTTPPort       string
+	IdempotencyTTL time.Duration
+	// NotFoundCacheTTL — сколько помнить, что заказа нет в БД; 0 отключает отрицательное кеширование.
+	// Это же верхняя граница, сколько другая реплика может отвечать 404 на только что созданный заказ.
+	NotFoundCacheTTL time.Duration
+	// SaveCachePolicy — политика кеша при сохранении заказа: write-through, invalidate или none
+	SaveCachePolicy string
+	// KafkaStatusTopic — топик событий смены статуса заказа; пустое значение отключает их обработку
+	KafkaStatusTopic string
+
+	// PostgresReplicaDSNs — реплики для чтения; пустой список — все запросы идут в primary
+	PostgresReplicaDSNs        []string
+	ReplicaHealthCheckInterval time.Duration
+	// ReadYourWritesWindow — сколько после записи заказа читать его из primary; 0 отключает закрепление
+	ReadYourWritesWindow time.Duration
+
+	// CacheBackend — реализация кеша заказов: local (в памяти процесса), lru (ограниченный по размеру),
+	// redis (общий для реплик) или tiered (lru перед redis)
+	CacheBackend      string
+	RedisAddr         string
+	RedisPassword     string
+	RedisDB           int
+	RedisKeyPrefix    string
+	RedisTTL          time.Duration
+	RedisCodec        string
+	RedisPoolSize     int
+	RedisMinIdleConns int
+
+	L1CacheSize              int
+	L1CacheTTL               time.Duration
+	CacheInvalidationChannel string
+
+	// CacheSnapshotPath — файл снимка локального кеша; пустое значение отключает снимки
+	CacheSnapshotPath     string
+	CacheSnapshotInterval time.Duration
+	CacheSnapshotOverlap  time.Duration
+	// CacheEncodedResponses — хранить в кеше готовые тела ответов рядом с заказами (только local, lru и tiered)
+	CacheEncodedResponses bool
+	// OrderCacheControl — значение Cache-Control для ответов с заказом
+	OrderCacheControl  string
+	CORSAllowedOrigins []string
+
+	GetOrderRateLimits    protocols.RouteLimits
+	CreateOrderRateLimits protocols.RouteLimits
+	CacheAdminRateLimits  protocols.RouteLimits
+	TrustForwardedFor     bool
+	MaxConcurrentRequests int
+	MaxQueueWait          time.Duration
+
+	AuthAPIKeysFile string
+	AuthJWKSFile    string
+	AuthJWTIssuer   string
+	AuthJWTAudience string
+
+	// ReportingCurrency — валюта, в которую пересчитываются суммы оплаты в ответе; пустое значение отключает пересчёт
+	ReportingCurrency string
+	ExchangeRatesFile string
 }
 
 func Load() *Config {
 	return &Config{
-		KafkaBrokers: os.Getenv("KAFKA_BROKERS"),
-		KafkaTopic:   os.Getenv("KAFKA_TOPIC"),
-		KafkaGroupID: os.Getenv("KAFKA_GROUP_ID"),
-		PostgresDSN:  os.Getenv("POSTGRES_DSN"),
-		HTTPPort:     os.Getenv("HTTP_PORT"),
+		KafkaBrokers:     os.Getenv("KAFKA_BROKERS"),
+		KafkaTopic:       os.Getenv("KAFKA_TOPIC"),
+		KafkaGroupID:     os.Getenv("KAFKA_GROUP_ID"),
+		DatabaseDSN:      getString("DATABASE_DSN", os.Getenv("POSTGRES_DSN")),
+		KafkaStatusTopic: os.Getenv("KAFKA_STATUS_TOPIC"),
+		HTTPPort:         os.Getenv("HTTP_PORT"),
+		IdempotencyTTL:   getDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		NotFoundCacheTTL: getDuration("NOT_FOUND_CACHE_TTL", 5*time.Second),
+		SaveCachePolicy:  getString("SAVE_CACHE_POLICY", "write-through"),
+
+		PostgresReplicaDSNs:        getList("POSTGRES_REPLICA_DSNS"),
+		ReplicaHealthCheckInterval: getDuration("POSTGRES_REPLICA_HEALTH_INTERVAL", 5*time.Second),
+		ReadYourWritesWindow:       getDuration("POSTGRES_READ_YOUR_WRITES_WINDOW", 5*time.Second),
+
+		CacheBackend:      getString("CACHE_BACKEND", "local"),
+		RedisAddr:         getString("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:     os.Getenv("REDIS_PASSWORD"),
+		RedisDB:           getInt("REDIS_DB", 0),
+		RedisKeyPrefix:    getString("REDIS_KEY_PREFIX", "orders:"),
+		RedisTTL:          getDuration("REDIS_TTL", 0),
+		RedisCodec:        getString("REDIS_CODEC", "json"),
+		RedisPoolSize:     getInt("REDIS_POOL_SIZE", 20),
+		RedisMinIdleConns: getInt("REDIS_MIN_IDLE_CONNS", 2),
+
+		L1CacheSize:              getInt("L1_CACHE_SIZE", 10000),
+		L1CacheTTL:               getDuration("L1_CACHE_TTL", time.Minute),
+		CacheInvalidationChannel: getString("CACHE_INVALIDATION_CHANNEL", "orders:invalidate"),
+
+		CacheSnapshotPath:     os.Getenv("CACHE_SNAPSHOT_PATH"),
+		CacheSnapshotInterval: getDuration("CACHE_SNAPSHOT_INTERVAL", 5*time.Minute),
+		CacheSnapshotOverlap:  getDuration("CACHE_SNAPSHOT_OVERLAP", time.Hour),
+		CacheEncodedResponses: getBool("CACHE_ENCODED_RESPONSES", true),
+
+		OrderCacheControl:  getString("HTTP_ORDER_CACHE_CONTROL", "private, no-cache"),
+		CORSAllowedOrigins: getList("HTTP_CORS_ALLOWED_ORIGINS"),
+		GetOrderRateLimits: protocols.RouteLimits{
+			PerIP:  getRateLimit("HTTP_RATE_LIMIT_GET_ORDER_IP", protocols.RateLimit{RPS: 20, Burst: 40}),
+			PerKey: getRateLimit("HTTP_RATE_LIMIT_GET_ORDER_KEY", protocols.RateLimit{RPS: 50, Burst: 100}),
+		},
+		CreateOrderRateLimits: protocols.RouteLimits{
+			PerIP:  getRateLimit("HTTP_RATE_LIMIT_CREATE_ORDER_IP", protocols.RateLimit{RPS: 5, Burst: 10}),
+			PerKey: getRateLimit("HTTP_RATE_LIMIT_CREATE_ORDER_KEY", protocols.RateLimit{RPS: 20, Burst: 40}),
+		},
+		// Admin API вызывается редко и руками, а сброс и прогрев кеша нагружают БД, поэтому лимиты строже
+		CacheAdminRateLimits: protocols.RouteLimits{
+			PerIP:  getRateLimit("HTTP_RATE_LIMIT_CACHE_ADMIN_IP", protocols.RateLimit{RPS: 1, Burst: 5}),
+			PerKey: getRateLimit("HTTP_RATE_LIMIT_CACHE_ADMIN_KEY", protocols.RateLimit{RPS: 1, Burst: 5}),
+		},
+		TrustForwardedFor:     getBool("HTTP_TRUST_FORWARDED_FOR", false),
+		MaxConcurrentRequests: getInt("HTTP_MAX_CONCURRENT_REQUESTS", 100),
+		MaxQueueWait:          getDuration("HTTP_MAX_QUEUE_WAIT", 50*time.Millisecond),
+		AuthAPIKeysFile:       os.Getenv("AUTH_API_KEYS_FILE"),
+		AuthJWKSFile:          os.Getenv("AUTH_JWKS_FILE"),
+		AuthJWTIssuer:         os.Getenv("AUTH_JWT_ISSUER"),
+		AuthJWTAudience:       os.Getenv("AUTH_JWT_AUDIENCE"),
+
+		ReportingCurrency: os.Getenv("REPORTING_CURRENCY"),
+		ExchangeRatesFile: os.Getenv("EXCHANGE_RATES_FILE"),
+	}
+}
+
+func getString(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func getList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func getInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer %q in %s, using default %d\n", value, key, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean %q in %s, using default %t\n", value, key, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+// getRateLimit разбирает значение вида "rps:burst", например "20:40"; "0" отключает лимит
+func getRateLimit(key string, defaultValue protocols.RateLimit) protocols.RateLimit {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	rpsPart, burstPart, _ := strings.Cut(value, ":")
+	rps, err := strconv.ParseFloat(rpsPart, 64)
+	if err != nil {
+		log.Printf("Invalid rate limit %q in %s, using default\n", value, key)
+		return defaultValue
+	}
+	limit := protocols.RateLimit{RPS: rps}
+	if burstPart != "" {
+		if limit.Burst, err = strconv.Atoi(burstPart); err != nil {
+			log.Printf("Invalid rate limit burst %q in %s, using default\n", value, key)
+			return defaultValue
+		}
+	}
+	return limit
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration %q in %s, using default %s\n", value, key, defaultValue)
+		return defaultValue
 	}
+	return d
 }
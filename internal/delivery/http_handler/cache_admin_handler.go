@@ -0,0 +1,171 @@
+package http_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"web_service/internal/usecase"
+)
+
+const (
+	defaultKeysPageSize = 100
+	maxKeysPageSize     = 1000
+)
+
+type cacheStatsResponse struct {
+	Entries     int        `json:"entries"`
+	Bytes       int64      `json:"bytes"`
+	Hits        uint64     `json:"hits"`
+	Misses      uint64     `json:"misses"`
+	HitRatio    float64    `json:"hit_ratio"`
+	OldestEntry *time.Time `json:"oldest_entry,omitempty"`
+}
+
+type cacheKeysResponse struct {
+	Keys []string `json:"keys"`
+	// NextCursor передаётся в after= для следующей страницы; пустой — страниц больше нет
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type warmUpResponse struct {
+	Running    bool       `json:"running"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Total      int        `json:"total"`
+	Loaded     int        `json:"loaded"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// CacheAdminHandler обслуживает admin API кеша заказов; доступ есть только у роли admin
+type CacheAdminHandler struct {
+	useCase *usecase.CacheAdminUseCase
+}
+
+func NewCacheAdminHandler(useCase *usecase.CacheAdminUseCase) *CacheAdminHandler {
+	return &CacheAdminHandler{useCase: useCase}
+}
+
+func (h *CacheAdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.useCase.Stats()
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	response := cacheStatsResponse{Entries: stats.Entries, Bytes: stats.Bytes, Hits: stats.Hits, Misses: stats.Misses}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		response.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	response.OldestEntry = optionalTime(stats.OldestEntry)
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+func (h *CacheAdminHandler) Keys(w http.ResponseWriter, r *http.Request) {
+	limit := defaultKeysPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxKeysPageSize {
+			writeProblem(w, newProblem(r, http.StatusBadRequest, CodeInvalidRequest,
+				"limit must be an integer between 1 and "+strconv.Itoa(maxKeysPageSize)))
+			return
+		}
+		limit = parsed
+	}
+	keys, err := h.useCase.Keys(r.URL.Query().Get("after"), limit)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	response := cacheKeysResponse{Keys: keys}
+	if len(keys) == limit {
+		response.NextCursor = keys[len(keys)-1]
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+func (h *CacheAdminHandler) Evict(w http.ResponseWriter, r *http.Request) {
+	h.useCase.Evict(r.PathValue("order_uid"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CacheAdminHandler) Flush(w http.ResponseWriter, r *http.Request) {
+	if err := h.useCase.Flush(); err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartWarmUp отвечает 202 сразу после запуска; ход прогрева отдаёт WarmUpStatus
+func (h *CacheAdminHandler) StartWarmUp(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.useCase.StartWarmUp()
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", apiPrefix+"/admin/cache/warm-up")
+	writeJSON(w, r, http.StatusAccepted, toWarmUpResponse(progress))
+}
+
+func (h *CacheAdminHandler) WarmUpStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, toWarmUpResponse(h.useCase.WarmUpProgress()))
+}
+
+func toWarmUpResponse(progress usecase.WarmUpProgress) warmUpResponse {
+	return warmUpResponse{
+		Running:    progress.Running,
+		StartedAt:  optionalTime(progress.StartedAt),
+		FinishedAt: optionalTime(progress.FinishedAt),
+		Total:      progress.Total,
+		Loaded:     progress.Loaded,
+		Failed:     progress.Failed,
+		Error:      progress.Error,
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, usecase.CacheAdminUnsupportedError):
+		writeProblem(w, newProblem(r, http.StatusNotImplemented, CodeNotSupported, err.Error()))
+	case errors.Is(err, usecase.WarmUpInProgressError):
+		writeProblem(w, newProblem(r, http.StatusConflict, CodeWarmUpInProgress, err.Error()))
+	default:
+		writeInternalError(w, r, err)
+	}
+}
+
+// withCacheAdminAccess пропускает только клиентов с ролью admin
+func withCacheAdminAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !PrincipalFromContext(r.Context()).CanAdministerCache() {
+			writeProblem(w, newProblem(r, http.StatusForbidden, CodeAccessDenied,
+				"Cache administration requires the admin role"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("[%s] failed to write response: %v\n", CorrelationIDFromContext(r.Context()), err)
+	}
+}
@@ -0,0 +1,90 @@
+package http_handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Тела меньше этого размера не сжимаем: выигрыш не окупает заголовки и CPU
+const minCompressSize = 1024
+
+// Порядок определяет предпочтение сервера при одинаковом q у клиента
+var supportedEncodings = []string{"br", "zstd", "gzip"}
+
+// zstd.Encoder потокобезопасен при использовании EncodeAll
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+
+// negotiateEncoding выбирает кодирование по Accept-Encoding; пустая строка — без сжатия
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := weights[encoding]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func compress(body []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "zstd":
+		return zstdEncoder.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	case "br":
+		var buf bytes.Buffer
+		writer := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "gzip":
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return body, nil
+	}
+}
+
+// encodedETag делает ETag уникальным для каждого кодирования представления
+func encodedETag(etag, encoding string) string {
+	if encoding == "" {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
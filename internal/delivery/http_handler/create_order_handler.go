@@ -0,0 +1,150 @@
+package http_handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+	"web_service/internal/usecase"
+)
+
+const maxOrderBodySize = 1 << 20
+
+type CreateOrderHandler struct {
+	useCase     *usecase.SaveOrderUseCase
+	idempotency protocols.IdempotencyStorageInterface
+}
+
+func NewCreateOrderHandler(useCase *usecase.SaveOrderUseCase,
+	idempotency protocols.IdempotencyStorageInterface) *CreateOrderHandler {
+	return &CreateOrderHandler{useCase: useCase, idempotency: idempotency}
+}
+
+func (h *CreateOrderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOrderBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(w, newProblem(r, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+				"Request body must not exceed 1 MiB"))
+			return
+		}
+		writeProblem(w, newProblem(r, http.StatusBadRequest, CodeInvalidRequest,
+			"Request body could not be read"))
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		writeRecord(w, h.create(r, body))
+		return
+	}
+	// Ключи разных клиентов не должны пересекаться
+	key = PrincipalFromContext(r.Context()).Subject + ":" + key
+	fingerprint := bodyFingerprint(body)
+	if record, reserved := h.idempotency.Reserve(key, fingerprint); !reserved {
+		switch {
+		case record.Fingerprint != fingerprint:
+			writeProblem(w, newProblem(r, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused,
+				"Idempotency-Key was already used with a different request body"))
+		case record.InFlight():
+			writeProblem(w, newProblem(r, http.StatusConflict, CodeIdempotencyRequestInProgress,
+				"A request with this Idempotency-Key is still being processed"))
+		default:
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeRecord(w, record)
+		}
+		return
+	}
+
+	var record *protocols.IdempotencyRecord
+	defer func() {
+		// Ответы 5xx (и паника) снимают резерв: клиент должен иметь возможность повторить запрос
+		if record == nil || record.StatusCode >= http.StatusInternalServerError {
+			h.idempotency.Release(key)
+		}
+	}()
+	record = h.create(r, body)
+	record.Fingerprint = fingerprint
+	if record.StatusCode < http.StatusInternalServerError {
+		h.idempotency.Save(key, record)
+	}
+	writeRecord(w, record)
+}
+
+func (h *CreateOrderHandler) create(r *http.Request, body []byte) *protocols.IdempotencyRecord {
+	var order domain.Order
+	if err := json.Unmarshal(body, &order); err != nil {
+		return problemRecord(newProblem(r, http.StatusBadRequest, CodeInvalidRequest,
+			"Request body is not a valid order JSON"))
+	}
+	if !PrincipalFromContext(r.Context()).CanCreate(&order) {
+		return problemRecord(newProblem(r, http.StatusForbidden, CodeAccessDenied,
+			"Not allowed to create orders for delivery service "+order.DeliveryService))
+	}
+	err := h.useCase.Save(r.Context(), &order)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			problem := newProblem(r, http.StatusUnprocessableEntity, CodeValidationFailed,
+				"Order validation failed")
+			problem.Violations = validationErr.Violations
+			return problemRecord(problem)
+		case errors.Is(err, domain.OrderAlreadyExistsError):
+			return problemRecord(newProblem(r, http.StatusConflict, CodeOrderAlreadyExists,
+				"Order "+order.OrderUID+" already exists"))
+		}
+		problem := errorProblem(r, err)
+		if problem == nil {
+			problem = internalProblem(r)
+		}
+		log.Printf("[%s] failed to save order %s: %v\n", problem.CorrelationID, order.OrderUID, err)
+		return problemRecord(problem)
+	}
+	payload, err := json.Marshal(&order)
+	if err != nil {
+		problem := internalProblem(r)
+		log.Printf("[%s] failed to encode order %s: %v\n", problem.CorrelationID, order.OrderUID, err)
+		return problemRecord(problem)
+	}
+	return &protocols.IdempotencyRecord{
+		StatusCode:  http.StatusCreated,
+		ContentType: "application/json",
+		Location:    OrderPath(order.OrderUID),
+		Body:        payload,
+	}
+}
+
+func problemRecord(problem *Problem) *protocols.IdempotencyRecord {
+	body, _ := json.Marshal(problem)
+	return &protocols.IdempotencyRecord{
+		StatusCode:  problem.Status,
+		ContentType: problemContentType,
+		Body:        body,
+	}
+}
+
+func bodyFingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeRecord(w http.ResponseWriter, record *protocols.IdempotencyRecord) {
+	if record.Location != "" {
+		w.Header().Set("Location", record.Location)
+	}
+	if record.StatusCode == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+	w.Header().Set("Content-Type", record.ContentType)
+	w.WriteHeader(record.StatusCode)
+	if _, err := w.Write(record.Body); err != nil {
+		log.Printf("failed to write response: %v\n", err)
+	}
+}
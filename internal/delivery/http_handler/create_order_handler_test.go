@@ -0,0 +1,78 @@
+package http_handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+	"web_service/internal/infrastructure/cache"
+	"web_service/internal/protocols"
+	"web_service/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCreateTestRouter(idempotency protocols.IdempotencyStorageInterface) http.Handler {
+	storage := cache.NewLocalOrderStorage()
+	getUseCase := usecase.NewGetOrderUseCase(nil, nil, nil, nil, nil, nil, nil, storage, cache.NewLocalNotFoundCache(0), nil)
+	return NewRouter(RouterConfig{Authenticators: Authenticators{APIKey: staticAuthenticator{}}},
+		NewOrderHandler(getUseCase, "private, no-cache", storage, nil),
+		NewCreateOrderHandler(nil, idempotency),
+		NewCacheAdminHandler(usecase.NewCacheAdminUseCase(context.Background(), getUseCase, storage)))
+}
+
+func newCreateRequest(body, key string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(body))
+	req.Header.Set(apiKeyHeader, "support")
+	req.Header.Set("Idempotency-Key", key)
+	return req
+}
+
+func TestCreateOrderRejectsDuplicateWhileInFlight(t *testing.T) {
+	idempotency := cache.NewLocalIdempotencyStorage(time.Hour)
+	router := newCreateTestRouter(idempotency)
+	body := `{"order_uid":"uid"}`
+	_, reserved := idempotency.Reserve("support:k1", bodyFingerprint([]byte(body)))
+	require.True(t, reserved)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newCreateRequest(body, "k1"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), CodeIdempotencyRequestInProgress)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newCreateRequest(`{"order_uid":"other"}`, "k1"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// Отказ дубликату не затирает готовый ответ исходного запроса
+	idempotency.Save("support:k1", &protocols.IdempotencyRecord{
+		Fingerprint: bodyFingerprint([]byte(body)),
+		StatusCode:  http.StatusCreated,
+		ContentType: "application/json",
+		Body:        []byte(body),
+	})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newCreateRequest(body, "k1"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+}
+
+func TestCreateOrderMapsBodyReadErrors(t *testing.T) {
+	router := newCreateTestRouter(cache.NewLocalIdempotencyStorage(time.Hour))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newCreateRequest(strings.Repeat("x", maxOrderBodySize+1), "big"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req := newCreateRequest("", "broken")
+	req.Body = io.NopCloser(iotest.ErrReader(assert.AnError))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), CodeInvalidRequest)
+}
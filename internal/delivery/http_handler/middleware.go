@@ -0,0 +1,107 @@
+package http_handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+
+	"github.com/google/uuid"
+)
+
+const (
+	correlationIDHeader = "X-Request-ID"
+	apiKeyHeader        = "X-API-Key"
+)
+
+type correlationIDKey struct{}
+
+type principalKey struct{}
+
+func CorrelationIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+func PrincipalFromContext(ctx context.Context) *domain.Principal {
+	if p, ok := ctx.Value(principalKey{}).(*domain.Principal); ok {
+		return p
+	}
+	return nil
+}
+
+// withCorrelationID берёт идентификатор запроса из X-Request-ID или генерирует новый
+func withCorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(correlationIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(correlationIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), correlationIDKey{}, id)))
+	})
+}
+
+// withCORS разрешает кросс-доменные запросы только с перечисленных origin; "*" разрешает все.
+// Preflight-запросы обрабатываются здесь же и не доходят до аутентификации.
+func withCORS(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAll := slices.Contains(allowedOrigins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !allowAll && !slices.Contains(allowedOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", "Location, ETag, "+correlationIDHeader)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers",
+				"Authorization, Content-Type, Idempotency-Key, If-None-Match, "+apiKeyHeader+", "+correlationIDHeader)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authenticators — поддерживаемые схемы аутентификации; nil отключает схему
+type Authenticators struct {
+	APIKey protocols.AuthenticatorInterface
+	Bearer protocols.AuthenticatorInterface
+}
+
+func (a Authenticators) authenticate(r *http.Request) (*domain.Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" && a.APIKey != nil {
+		return a.APIKey.Authenticate(r.Context(), key)
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && a.Bearer != nil {
+		return a.Bearer.Authenticate(r.Context(), strings.TrimSpace(token))
+	}
+	return nil, domain.AuthenticationFailedError
+}
+
+func withAuthentication(auth Authenticators, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.authenticate(r)
+		if err != nil {
+			log.Printf("[%s] authentication failed: %v\n", CorrelationIDFromContext(r.Context()), err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="orders", ApiKey realm="orders"`)
+			writeProblem(w, newProblem(r, http.StatusUnauthorized, CodeUnauthenticated,
+				"Valid API key or bearer token is required"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
@@ -1,51 +1,186 @@
 package http_handler
 
 import (
-	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
-	"strings"
+	"time"
 	"web_service/internal/domain"
+	"web_service/internal/protocols"
 	"web_service/internal/usecase"
 )
 
 type GetOrderHandler struct {
-	useCase *usecase.GetOrderUseCase
+	useCase         *usecase.GetOrderUseCase
+	cacheControl    string
+	representations protocols.RepresentationStorageInterface
+	reporting       *ReportingCurrency
 }
 
-func NewOrderHandler(useCase *usecase.GetOrderUseCase) *GetOrderHandler {
-	return &GetOrderHandler{useCase: useCase}
+// ReportingCurrency — валюта отчётности и курсы, по которым в ответ добавляется payment.reporting
+type ReportingCurrency struct {
+	Currency domain.Currency
+	Rates    *domain.ExchangeRates
+}
+
+// NewOrderHandler создаёт обработчик чтения заказа. Если representations не nil, полные представления
+// заказа кешируются уже закодированными и повторно отдаются без сериализации. reporting == nil
+// отключает пересчёт сумм в валюту отчётности.
+func NewOrderHandler(useCase *usecase.GetOrderUseCase, cacheControl string,
+	representations protocols.RepresentationStorageInterface, reporting *ReportingCurrency) *GetOrderHandler {
+	return &GetOrderHandler{useCase: useCase, cacheControl: cacheControl, representations: representations,
+		reporting: reporting}
 }
 
 func (h *GetOrderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	if r.Method != http.MethodGet {
-		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	orderUID := r.PathValue("order_uid")
+	if orderUID == "" {
+		writeProblem(w, newProblem(r, http.StatusBadRequest, CodeInvalidRequest, "order_uid is required"))
 		return
 	}
-	// Извлекаем order_uid из URL пути
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 3 || pathParts[2] == "" {
-		http.Error(w, `{"error": "Order ID is required"}`, http.StatusBadRequest)
+	opts, err := parseShapeOptions(r.URL.Query())
+	if err != nil {
+		writeProblem(w, newProblem(r, http.StatusBadRequest, CodeInvalidRequest, err.Error()))
 		return
 	}
-	orderUID := pathParts[2]
-	if orderUID == "" {
-		http.Error(w, `{"error": "order_uid parameter is required"}`, http.StatusBadRequest)
-		return
+	principal := PrincipalFromContext(r.Context())
+	opts.maskPII = !principal.SeesFullPII()
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+
+	// Кешируются только полные представления: проекций по fields= слишком много
+	variant, generation := "", uint64(0)
+	if h.representations != nil && opts.isFull() {
+		variant = representationVariant(opts.maskPII, encoding)
+		representation, gen, ok := h.representations.GetRepresentation(orderUID, variant)
+		if ok {
+			if !principal.CanViewDeliveryService(representation.DeliveryService) {
+				writeOrderNotFound(w, r, orderUID)
+				return
+			}
+			h.serve(w, r, representation, false)
+			return
+		}
+		generation = gen
+	}
+
+	order, err := h.useCase.GetOrder(r.Context(), orderUID, opts.parts)
+	if err == nil && !principal.CanView(order) {
+		// Чужие заказы неотличимы от несуществующих, чтобы не раскрывать их наличие
+		err = domain.OrderNotFoundError
 	}
-	order, err := h.useCase.GetOrderById(orderUID)
 	if err != nil {
-		if errors.Is(err, domain.OrderNotFoundError) {
-			http.Error(w, `{"error": "Order not found"}`, http.StatusNotFound)
+		// Заказ без доставки тоже считается ненайденным; без счёта он отдаётся с пустой оплатой
+		if errors.Is(err, domain.NotFoundError) {
+			writeOrderNotFound(w, r, orderUID)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	err = json.NewEncoder(w).Encode(order)
+	if h.reporting != nil && opts.parts.Has(domain.PartPayment) {
+		order = h.withReporting(r, order)
+	}
+	representation, err := render(order, opts, encoding)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if variant == "" {
+		// Без кеша сжимаем только тогда, когда клиенту действительно нужно тело
+		h.serve(w, r, representation, true)
+		return
+	}
+	if representation.Body, err = compress(representation.Body, representation.Encoding); err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	h.representations.SaveRepresentation(orderUID, variant, generation, representation)
+	h.serve(w, r, representation, false)
+}
+
+// withReporting возвращает копию заказа с суммами в валюте отчётности. Без курса для валюты заказа
+// ответ отдаётся без пересчёта: это не повод отказывать в чтении заказа.
+func (h *GetOrderHandler) withReporting(r *http.Request, order *domain.Order) *domain.Order {
+	reporting, err := order.Payment.ConvertTo(h.reporting.Rates, h.reporting.Currency)
+	if err != nil {
+		log.Printf("[%s] order %s: %v\n", CorrelationIDFromContext(r.Context()), order.OrderUID, err)
+		return order
+	}
+	converted := *order
+	converted.Payment.Reporting = reporting
+	return &converted
+}
+
+// render сериализует заказ; тело остаётся несжатым, а ETag уже учитывает выбранное кодирование
+func render(order *domain.Order, opts *shapeOptions, encoding string) (*protocols.Representation, error) {
+	body, err := shapeOrder(order, opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, err
+	}
+	if len(body) < minCompressSize {
+		encoding = ""
+	}
+	return &protocols.Representation{
+		Body:            body,
+		ETag:            encodedETag(strongETag(body), encoding),
+		Encoding:        encoding,
+		LastModified:    lastModified(order),
+		DeliveryService: order.DeliveryService,
+	}, nil
+}
+
+// lastModified — время последнего изменения заказа. date_created задаёт отправитель, и его часы могут
+// спешить, поэтому берётся более позднее из двух времён.
+func lastModified(order *domain.Order) time.Time {
+	if order.DateCreated.After(order.UpdatedAt) {
+		return order.DateCreated
+	}
+	return order.UpdatedAt
+}
+
+func (h *GetOrderHandler) serve(w http.ResponseWriter, r *http.Request, representation *protocols.Representation,
+	compressBody bool) {
+	w.Header().Add("Vary", "Accept-Encoding, Authorization, "+apiKeyHeader)
+	setValidators(w, representation.ETag, representation.LastModified, h.cacheControl)
+	if notModified(r, representation.ETag, representation.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body := representation.Body
+	if compressBody {
+		var err error
+		if body, err = compress(body, representation.Encoding); err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+	}
+	if representation.Encoding != "" {
+		w.Header().Set("Content-Encoding", representation.Encoding)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		log.Printf("[%s] failed to write response: %v\n", CorrelationIDFromContext(r.Context()), err)
+	}
+}
+
+func writeOrderNotFound(w http.ResponseWriter, r *http.Request, orderUID string) {
+	writeProblem(w, newProblem(r, http.StatusNotFound, CodeOrderNotFound, "Order "+orderUID+" not found"))
+}
+
+// Имена вариантов заранее посчитаны, чтобы попадание в кеш не собирало строку на каждый запрос
+var representationVariants = func() [2]map[string]string {
+	variants := [2]map[string]string{{"": "full"}, {"": "masked"}}
+	for _, encoding := range supportedEncodings {
+		variants[0][encoding] = "full/" + encoding
+		variants[1][encoding] = "masked/" + encoding
+	}
+	return variants
+}()
+
+func representationVariant(maskPII bool, encoding string) string {
+	if maskPII {
+		return representationVariants[1][encoding]
 	}
+	return representationVariants[0][encoding]
 }
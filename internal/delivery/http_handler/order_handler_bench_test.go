@@ -0,0 +1,77 @@
+package http_handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/cache"
+	"web_service/internal/usecase"
+)
+
+func benchmarkOrder() *domain.Order {
+	order := &domain.Order{
+		OrderUID:        "bench",
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery: domain.Delivery{Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com"},
+		Payment: domain.Payment{Transaction: "bench", Currency: "USD", Provider: "wbpay", Amount: 4670,
+			PaymentDt: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 3170},
+	}
+	for i := 0; i < 10; i++ {
+		order.Items = append(order.Items, domain.Item{ChrtID: 9934930 + i, TrackNumber: "WBILMTESTTRACK", Price: 453,
+			Rid: "ab4219087a764ae0btest" + strconv.Itoa(i), Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317,
+			NmID: 2389212, Brand: "Vivienne Sabo", Status: 202})
+	}
+	return order
+}
+
+func benchmarkRouter(preSerialized bool) http.Handler {
+	storage := cache.NewLocalOrderStorage()
+	storage.Save("bench", benchmarkOrder())
+	getUseCase := usecase.NewGetOrderUseCase(nil, nil, nil, nil, nil, nil, nil, storage, cache.NewLocalNotFoundCache(0), nil)
+	handler := NewOrderHandler(getUseCase, "private, no-cache", nil, nil)
+	if preSerialized {
+		handler = NewOrderHandler(getUseCase, "private, no-cache", storage, nil)
+	}
+	return NewRouter(RouterConfig{Authenticators: Authenticators{APIKey: staticAuthenticator{}}},
+		handler, NewCreateOrderHandler(nil, cache.NewLocalIdempotencyStorage(0)), nil)
+}
+
+// BenchmarkGetOrder сравнивает сериализацию на каждый запрос с отдачей готового тела из кеша
+func BenchmarkGetOrder(b *testing.B) {
+	for _, mode := range []struct {
+		name          string
+		preSerialized bool
+	}{{"encode", false}, {"pre-serialized", true}} {
+		for _, acceptEncoding := range []string{"", "gzip"} {
+			name := mode.name + "/identity"
+			if acceptEncoding != "" {
+				name = mode.name + "/" + acceptEncoding
+			}
+			b.Run(name, func(b *testing.B) {
+				router := benchmarkRouter(mode.preSerialized)
+				req := newRequest(http.MethodGet, "/api/v1/orders/bench", "support")
+				req.Header.Set("Accept-Encoding", acceptEncoding)
+				router.ServeHTTP(httptest.NewRecorder(), req)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					rec := httptest.NewRecorder()
+					router.ServeHTTP(rec, req)
+					if rec.Code != http.StatusOK {
+						b.Fatalf("unexpected status %d", rec.Code)
+					}
+				}
+			})
+		}
+	}
+}
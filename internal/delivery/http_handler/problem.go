@@ -0,0 +1,126 @@
+package http_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"web_service/internal/domain"
+)
+
+const problemContentType = "application/problem+json"
+
+// Стабильные коды ошибок API. Клиенты должны опираться на них, а не на текст detail.
+const (
+	CodeInvalidRequest               = "invalid_request"
+	CodeValidationFailed             = "validation_failed"
+	CodeOrderNotFound                = "order_not_found"
+	CodeOrderAlreadyExists           = "order_already_exists"
+	CodeIdempotencyKeyReused         = "idempotency_key_reused"
+	CodeIdempotencyRequestInProgress = "idempotency_request_in_progress"
+	CodePayloadTooLarge              = "payload_too_large"
+	CodeUnauthenticated              = "unauthenticated"
+	CodeAccessDenied                 = "access_denied"
+	CodeRateLimited                  = "rate_limited"
+	CodeOverloaded                   = "overloaded"
+	CodeRouteNotFound                = "route_not_found"
+	CodeMethodNotAllowed             = "method_not_allowed"
+	CodeNotFound                     = "not_found"
+	CodeConflict                     = "conflict"
+	CodeTemporarilyUnavailable       = "temporarily_unavailable"
+	CodeNotSupported                 = "not_supported"
+	CodeWarmUpInProgress             = "warm_up_in_progress"
+	CodeInternalError                = "internal_error"
+)
+
+// Problem — тело ошибки в формате RFC 7807
+type Problem struct {
+	Type          string             `json:"type"`
+	Title         string             `json:"title"`
+	Status        int                `json:"status"`
+	Detail        string             `json:"detail,omitempty"`
+	Instance      string             `json:"instance,omitempty"`
+	Code          string             `json:"code"`
+	CorrelationID string             `json:"correlation_id,omitempty"`
+	Violations    []domain.Violation `json:"violations,omitempty"`
+}
+
+func newProblem(r *http.Request, status int, code, detail string) *Problem {
+	return &Problem{
+		Type:          "/problems/" + code,
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      r.URL.Path,
+		Code:          code,
+		CorrelationID: CorrelationIDFromContext(r.Context()),
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		log.Printf("[%s] failed to encode problem: %v\n", problem.CorrelationID, err)
+		body = []byte(`{"type":"/problems/internal_error","title":"Internal Server Error","status":500,"code":"internal_error"}`)
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("[%s] failed to write response: %v\n", problem.CorrelationID, err)
+	}
+}
+
+func internalProblem(r *http.Request) *Problem {
+	return newProblem(r, http.StatusInternalServerError, CodeInternalError,
+		"Internal server error, use correlation_id when contacting support")
+}
+
+// writeInternalError логирует причину с идентификатором корреляции и не раскрывает её клиенту
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := internalProblem(r)
+	log.Printf("[%s] %s %s: %v\n", problem.CorrelationID, r.Method, r.URL.Path, err)
+	writeProblem(w, problem)
+}
+
+// errorProblem переводит категорию доменной ошибки в problem; для ошибок без категории возвращает nil.
+// Подробности из БД клиенту не раскрываются.
+func errorProblem(r *http.Request, err error) *Problem {
+	switch {
+	case errors.Is(err, domain.NotFoundError):
+		return newProblem(r, http.StatusNotFound, CodeNotFound, "Requested data not found")
+	case errors.Is(err, domain.ConflictError):
+		return newProblem(r, http.StatusConflict, CodeConflict, "Request conflicts with existing data")
+	case errors.Is(err, domain.StorageOverloadedError):
+		return newProblem(r, http.StatusServiceUnavailable, CodeOverloaded, "Service is overloaded, retry later")
+	case errors.Is(err, domain.RetryableError):
+		return newProblem(r, http.StatusServiceUnavailable, CodeTemporarilyUnavailable,
+			"Temporary failure, retry later")
+	}
+	return nil
+}
+
+// writeError отвечает по категории доменной ошибки, а всё остальное считает внутренней ошибкой
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := errorProblem(r, err)
+	if problem == nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	log.Printf("[%s] %s %s: %v\n", problem.CorrelationID, r.Method, r.URL.Path, err)
+	if problem.Status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+	writeProblem(w, problem)
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, newProblem(r, http.StatusNotFound, CodeRouteNotFound, "No route for "+r.URL.Path))
+}
+
+func methodNotAllowed(allowed string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allowed)
+		writeProblem(w, newProblem(r, http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+			"Method "+r.Method+" is not allowed, use "+allowed))
+	}
+}
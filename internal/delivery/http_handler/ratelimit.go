@@ -0,0 +1,169 @@
+package http_handler
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"web_service/internal/protocols"
+
+	"golang.org/x/time/rate"
+)
+
+// Лимитеры клиентов, не делавших запросов дольше этого времени, удаляются
+const limiterIdleTTL = 10 * time.Minute
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// keyedLimiter хранит отдельный token bucket на каждый ключ
+type keyedLimiter struct {
+	mu        sync.Mutex
+	limit     protocols.RateLimit
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+func newKeyedLimiter(limit protocols.RateLimit) *keyedLimiter {
+	if limit.RPS <= 0 {
+		return nil
+	}
+	if limit.Burst < 1 {
+		limit.Burst = int(math.Ceil(limit.RPS))
+	}
+	return &keyedLimiter{limit: limit, entries: make(map[string]*limiterEntry), lastSweep: time.Now()}
+}
+
+// reserve списывает токен и возвращает, через сколько можно повторить запрос, если токена не было
+func (l *keyedLimiter) reserve(key string) (time.Duration, bool) {
+	now := time.Now()
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, entry := range l.entries {
+			if now.Sub(entry.lastSeen) > limiterIdleTTL {
+				delete(l.entries, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(l.limit.RPS), l.limit.Burst)}
+		l.entries[key] = entry
+	}
+	entry.lastSeen = now
+	l.mu.Unlock()
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return 0, true
+	}
+	reservation.CancelAt(now)
+	return delay, false
+}
+
+func withRateLimit(limiter *keyedLimiter, keyFunc func(r *http.Request) string, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter, ok := limiter.reserve(keyFunc(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeProblem(w, newProblem(r, http.StatusTooManyRequests, CodeRateLimited,
+				"Rate limit exceeded, retry later"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается, только если сервис стоит за доверенным прокси:
+// берётся последний адрес, добавленный этим прокси.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func principalKeyFunc(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		return string(principal.Role) + ":" + principal.Subject
+	}
+	return ""
+}
+
+// withConcurrencyLimit ограничивает число одновременно обрабатываемых запросов. Запрос ждёт свободный слот
+// не дольше maxWait, а потом получает 503.
+func withConcurrencyLimit(limit int, maxWait time.Duration, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+	slots := make(chan struct{}, limit)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acquireSlot(r.Context(), slots, maxWait) {
+			writeOverloaded(w, r)
+			return
+		}
+		defer func() { <-slots }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withLoadShedding при насыщенном пуле БД сразу отвечает 503 вместо ожидания соединения до WriteTimeout.
+// Ставится только на маршруты, которые всегда идут в БД; чтение заказа проверяет пул само и только при промахе кеша.
+func withLoadShedding(probe protocols.LoadProbeInterface, next http.Handler) http.Handler {
+	if probe == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probe.Saturated() {
+			writeOverloaded(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}, maxWait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if maxWait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func writeOverloaded(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	writeProblem(w, newProblem(r, http.StatusServiceUnavailable, CodeOverloaded,
+		"Service is overloaded, retry later"))
+}
@@ -0,0 +1,51 @@
+package http_handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web_service/internal/protocols"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type saturatedProbe struct{}
+
+func (saturatedProbe) Saturated() bool { return true }
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+}
+
+func TestRateLimitRejectsOverBurstWithRetryAfter(t *testing.T) {
+	handler := withRateLimit(newKeyedLimiter(protocols.RateLimit{RPS: 1, Burst: 2}),
+		func(r *http.Request) string { return clientIP(r, false) }, okHandler())
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, rec.Code)
+		if rec.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		}
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+
+	other := httptest.NewRequest(http.MethodGet, "/", nil)
+	other.RemoteAddr = "10.0.0.2:1234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, other)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestLoadSheddingRejectsWhenPoolSaturated(t *testing.T) {
+	handler := withLoadShedding(saturatedProbe{}, okHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
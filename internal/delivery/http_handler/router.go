@@ -0,0 +1,78 @@
+package http_handler
+
+import (
+	"net/http"
+	"time"
+	"web_service/internal/protocols"
+)
+
+const apiPrefix = "/api/v1"
+
+// Имена маршрутов, по которым настраиваются лимиты запросов
+const (
+	RouteGetOrder    = "get_order"
+	RouteCreateOrder = "create_order"
+	RouteCacheAdmin  = "cache_admin"
+)
+
+func OrderPath(orderUID string) string {
+	return apiPrefix + "/orders/" + orderUID
+}
+
+type RouterConfig struct {
+	Authenticators Authenticators
+	AllowedOrigins []string
+
+	RateLimits        map[string]protocols.RouteLimits
+	TrustForwardedFor bool
+	// MaxConcurrentRequests ограничивает число запросов в обработке; 0 — без ограничения
+	MaxConcurrentRequests int
+	MaxQueueWait          time.Duration
+	// LoadProbe отсекает создание заказов при насыщенной БД; чтения проверяют её сами при промахе кеша
+	LoadProbe protocols.LoadProbeInterface
+}
+
+// NewRouter собирает маршруты API; adminHandler может быть nil, тогда admin API не публикуется
+func NewRouter(cfg RouterConfig, getHandler *GetOrderHandler, createHandler *CreateOrderHandler,
+	adminHandler *CacheAdminHandler) http.Handler {
+	mux := http.NewServeMux()
+
+	// Лимит по IP проверяется до аутентификации, чтобы ограничить и подбор ключей
+	route := func(name string, handler http.Handler) http.Handler {
+		limits := cfg.RateLimits[name]
+		ipKey := func(r *http.Request) string { return clientIP(r, cfg.TrustForwardedFor) }
+		handler = withRateLimit(newKeyedLimiter(limits.PerKey), principalKeyFunc, handler)
+		handler = withAuthentication(cfg.Authenticators, handler)
+		return withRateLimit(newKeyedLimiter(limits.PerIP), ipKey, handler)
+	}
+
+	getOrder := route(RouteGetOrder, getHandler)
+	mux.Handle("GET "+apiPrefix+"/orders/{order_uid}", getOrder)
+	mux.Handle(apiPrefix+"/orders/{order_uid}", methodNotAllowed(http.MethodGet))
+	mux.Handle("POST "+apiPrefix+"/orders", route(RouteCreateOrder, withLoadShedding(cfg.LoadProbe, createHandler)))
+	mux.Handle(apiPrefix+"/orders", methodNotAllowed(http.MethodPost))
+
+	if adminHandler != nil {
+		admin := func(handler http.HandlerFunc) http.Handler {
+			return route(RouteCacheAdmin, withCacheAdminAccess(handler))
+		}
+		mux.Handle("GET "+apiPrefix+"/admin/cache", admin(adminHandler.Stats))
+		mux.Handle("DELETE "+apiPrefix+"/admin/cache", admin(adminHandler.Flush))
+		mux.Handle(apiPrefix+"/admin/cache", methodNotAllowed("GET, DELETE"))
+		mux.Handle("GET "+apiPrefix+"/admin/cache/keys", admin(adminHandler.Keys))
+		mux.Handle(apiPrefix+"/admin/cache/keys", methodNotAllowed(http.MethodGet))
+		mux.Handle("DELETE "+apiPrefix+"/admin/cache/orders/{order_uid}", admin(adminHandler.Evict))
+		mux.Handle(apiPrefix+"/admin/cache/orders/{order_uid}", methodNotAllowed(http.MethodDelete))
+		mux.Handle("GET "+apiPrefix+"/admin/cache/warm-up", admin(adminHandler.WarmUpStatus))
+		mux.Handle("POST "+apiPrefix+"/admin/cache/warm-up", admin(adminHandler.StartWarmUp))
+		mux.Handle(apiPrefix+"/admin/cache/warm-up", methodNotAllowed("GET, POST"))
+	}
+
+	// Старый путь, который использует web-интерфейс и существующие клиенты
+	mux.Handle("GET /order/{order_uid}", getOrder)
+
+	mux.HandleFunc("/", notFoundHandler)
+
+	handler := withConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxQueueWait, mux)
+	return withCorrelationID(withCORS(cfg.AllowedOrigins, handler))
+}
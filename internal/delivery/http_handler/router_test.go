@@ -0,0 +1,195 @@
+package http_handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/cache"
+	"web_service/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticAuthenticator принимает ключ, совпадающий с именем роли
+type staticAuthenticator struct{}
+
+func (staticAuthenticator) Authenticate(_ context.Context, credential string) (*domain.Principal, error) {
+	role := domain.Role(credential)
+	if !role.Valid() {
+		return nil, domain.AuthenticationFailedError
+	}
+	return &domain.Principal{Subject: credential, Role: role, DeliveryService: "dhl"}, nil
+}
+
+func newTestRouter() http.Handler {
+	storage := cache.NewLocalOrderStorage()
+	storage.Save("cached", &domain.Order{
+		OrderUID:        "cached",
+		DeliveryService: "meest",
+		Delivery:        domain.Delivery{Phone: "+79991234567", Email: "test@gmail.com", Address: "Ploshad Mira 15"},
+	})
+	getUseCase := usecase.NewGetOrderUseCase(nil, nil, nil, nil, nil, nil, nil, storage, cache.NewLocalNotFoundCache(0), nil)
+	return NewRouter(RouterConfig{Authenticators: Authenticators{APIKey: staticAuthenticator{}}},
+		NewOrderHandler(getUseCase, "private, no-cache", storage, nil),
+		NewCreateOrderHandler(nil, cache.NewLocalIdempotencyStorage(0)),
+		NewCacheAdminHandler(usecase.NewCacheAdminUseCase(context.Background(), getUseCase, storage)))
+}
+
+func newRequest(method, target, role string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set(apiKeyHeader, role)
+	return req
+}
+
+func TestRouterServesVersionedOrderPath(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/orders/cached", "support"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+}
+
+func TestRouterRejectsUnknownPathsWithProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, newRequest(http.MethodGet, "/anything/cached", "support"))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, CodeRouteNotFound, problem.Code)
+}
+
+func TestRouterRejectsWrongMethodWithProblem(t *testing.T) {
+	req := newRequest(http.MethodDelete, "/api/v1/orders/cached", "support")
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodGet, rec.Header().Get("Allow"))
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, CodeMethodNotAllowed, problem.Code)
+	assert.Equal(t, "req-42", problem.CorrelationID)
+}
+
+func TestOrderHandlerHonoursIfNoneMatch(t *testing.T) {
+	router := newTestRouter()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/orders/cached", "support"))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+
+	req := newRequest(http.MethodGet, "/api/v1/orders/cached", "support")
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+}
+
+func TestRouterRequiresAuthentication(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/orders/cached", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestAnalyticsSeesMaskedContacts(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/orders/cached", "analytics"))
+
+	var order domain.Order
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &order))
+	assert.Equal(t, "+7********67", order.Delivery.Phone)
+	assert.Equal(t, "t***@gmail.com", order.Delivery.Email)
+	assert.Equal(t, "***", order.Delivery.Address)
+}
+
+func TestPartnerCannotSeeForeignOrders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/orders/cached", "partner"))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestOrderHandlerServesCachedRepresentation(t *testing.T) {
+	router := newTestRouter()
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, newRequest(http.MethodGet, "/api/v1/orders/cached", "support"))
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, newRequest(http.MethodGet, "/api/v1/orders/cached", "support"))
+
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+
+	// Ответ из готового представления тоже считается попаданием в кеш
+	stats := httptest.NewRecorder()
+	router.ServeHTTP(stats, newRequest(http.MethodGet, "/api/v1/admin/cache", "admin"))
+	var response cacheStatsResponse
+	assert.NoError(t, json.Unmarshal(stats.Body.Bytes(), &response))
+	assert.Equal(t, uint64(2), response.Hits)
+	assert.Equal(t, uint64(0), response.Misses)
+
+	// Закешированное полное представление не должно доставаться роли с маскированием
+	masked := httptest.NewRecorder()
+	router.ServeHTTP(masked, newRequest(http.MethodGet, "/api/v1/orders/cached", "analytics"))
+	assert.NotContains(t, masked.Body.String(), "+79991234567")
+
+	partner := httptest.NewRecorder()
+	router.ServeHTTP(partner, newRequest(http.MethodGet, "/api/v1/orders/cached", "partner"))
+	assert.Equal(t, http.StatusNotFound, partner.Code)
+}
+
+func TestCacheAdminRequiresAdminRole(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, newRequest(http.MethodDelete, "/api/v1/admin/cache", "support"))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, CodeAccessDenied, problem.Code)
+}
+
+func TestCacheAdminStatsKeysAndEvict(t *testing.T) {
+	router := newTestRouter()
+	router.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "/api/v1/orders/cached", "support"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/admin/cache", "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var stats cacheStatsResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, 1, stats.Entries)
+	assert.Positive(t, stats.Bytes)
+	assert.Equal(t, 1.0, stats.HitRatio)
+	assert.NotNil(t, stats.OldestEntry)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/admin/cache/keys?limit=1", "admin"))
+	var keys cacheKeysResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &keys))
+	assert.Equal(t, []string{"cached"}, keys.Keys)
+	assert.Equal(t, "cached", keys.NextCursor)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(http.MethodDelete, "/api/v1/admin/cache/orders/cached", "admin"))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/admin/cache/keys?after=cached", "admin"))
+	var lastPage cacheKeysResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lastPage))
+	assert.Empty(t, lastPage.Keys)
+	assert.Empty(t, lastPage.NextCursor)
+}
@@ -0,0 +1,193 @@
+package http_handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"web_service/internal/domain"
+)
+
+var includeParts = map[string]domain.OrderParts{
+	"delivery":       domain.PartDelivery,
+	"payment":        domain.PartPayment,
+	"items":          domain.PartItems,
+	"status_history": domain.PartStatusHistory,
+}
+
+// fieldTree — дерево запрошенных путей; nil у ключа означает «поле целиком»
+type fieldTree map[string]fieldTree
+
+// shapeOptions описывает, в каком виде клиент хочет получить заказ
+type shapeOptions struct {
+	parts   domain.OrderParts
+	fields  fieldTree
+	maskPII bool
+}
+
+func (o *shapeOptions) isFull() bool {
+	return o.parts == domain.AllOrderParts && o.fields == nil
+}
+
+// parseShapeOptions разбирает параметры include= и fields=
+func parseShapeOptions(query url.Values) (*shapeOptions, error) {
+	opts := &shapeOptions{parts: domain.AllOrderParts}
+
+	if query.Has("include") {
+		opts.parts = 0
+		for _, name := range splitList(query.Get("include")) {
+			part, ok := includeParts[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown include value %q, allowed: delivery, payment, items, status_history", name)
+			}
+			opts.parts |= part
+		}
+	}
+
+	if query.Has("fields") {
+		paths := splitList(query.Get("fields"))
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("fields must list at least one path")
+		}
+		opts.fields = fieldTree{}
+		for _, path := range paths {
+			segments := strings.Split(path, ".")
+			for _, segment := range segments {
+				if segment == "" {
+					return nil, fmt.Errorf("invalid field path %q", path)
+				}
+			}
+			opts.fields.add(segments)
+		}
+	}
+
+	return opts, nil
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (t fieldTree) add(segments []string) {
+	node := t
+	for i, segment := range segments {
+		child, exists := node[segment]
+		if i == len(segments)-1 {
+			node[segment] = nil
+			return
+		}
+		if exists && child == nil {
+			// Родительское поле уже запрошено целиком
+			return
+		}
+		if !exists {
+			child = fieldTree{}
+			node[segment] = child
+		}
+		node = child
+	}
+}
+
+// shapeOrder кодирует заказ в JSON с учётом include=, fields= и маскирования персональных данных
+func shapeOrder(order *domain.Order, opts *shapeOptions) ([]byte, error) {
+	if opts.maskPII {
+		order = maskedCopy(order)
+	}
+	if opts.isFull() {
+		return json.Marshal(order)
+	}
+	document, err := toDocument(order)
+	if err != nil {
+		return nil, err
+	}
+	for name, part := range includeParts {
+		if !opts.parts.Has(part) {
+			delete(document, name)
+		}
+	}
+	if opts.fields != nil {
+		return json.Marshal(project(document, opts.fields))
+	}
+	return json.Marshal(document)
+}
+
+// toDocument превращает заказ в универсальное JSON-дерево, сохраняя числа без потери точности
+func toDocument(order *domain.Order) (map[string]any, error) {
+	raw, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document map[string]any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func project(value any, tree fieldTree) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(tree))
+		for key, subtree := range tree {
+			field, ok := v[key]
+			if !ok {
+				continue
+			}
+			if subtree == nil {
+				result[key] = field
+			} else {
+				result[key] = project(field, subtree)
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, element := range v {
+			result[i] = project(element, tree)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// maskedCopy возвращает копию заказа со скрытыми контактами покупателя; исходный заказ из кеша не меняется
+func maskedCopy(order *domain.Order) *domain.Order {
+	masked := *order
+	masked.Delivery.Phone = maskPhone(order.Delivery.Phone)
+	masked.Delivery.Email = maskEmail(order.Delivery.Email)
+	masked.Delivery.Address = maskAll(order.Delivery.Address)
+	return &masked
+}
+
+func maskPhone(phone string) string {
+	runes := []rune(phone)
+	if len(runes) <= 4 {
+		return maskAll(phone)
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
+
+func maskEmail(email string) string {
+	local, host, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskAll(email)
+	}
+	return string([]rune(local)[:1]) + "***@" + host
+}
+
+func maskAll(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "***"
+}
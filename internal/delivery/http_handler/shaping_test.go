@@ -0,0 +1,52 @@
+package http_handler
+
+import (
+	"net/url"
+	"testing"
+	"web_service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeOrderProjectsFields(t *testing.T) {
+	order := &domain.Order{
+		OrderUID: "uid",
+		Payment:  domain.Payment{Amount: 1817, Currency: "USD"},
+		Items:    []domain.Item{{Name: "Mascaras", Price: 453}, {Name: "Lipstick", Price: 100}},
+	}
+	opts, err := parseShapeOptions(url.Values{"fields": {"order_uid,payment.amount,items.name"}})
+	assert.NoError(t, err)
+
+	body, err := shapeOrder(order, opts)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t,
+		`{"order_uid":"uid","payment":{"amount":1817},"items":[{"name":"Mascaras"},{"name":"Lipstick"}]}`,
+		string(body))
+}
+
+func TestShapeOrderOmitsExcludedParts(t *testing.T) {
+	opts, err := parseShapeOptions(url.Values{"include": {"payment"}})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PartPayment, opts.parts)
+
+	body, err := shapeOrder(&domain.Order{OrderUID: "uid"}, opts)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"payment"`)
+	assert.NotContains(t, string(body), `"items"`)
+	assert.NotContains(t, string(body), `"delivery"`)
+}
+
+func TestParseShapeOptionsRejectsUnknownInclude(t *testing.T) {
+	_, err := parseShapeOptions(url.Values{"include": {"customer"}})
+	assert.Error(t, err)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "", negotiateEncoding(""))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip, deflate"))
+	assert.Equal(t, "br", negotiateEncoding("gzip, br, zstd"))
+	assert.Equal(t, "zstd", negotiateEncoding("br;q=0.5, zstd"))
+	assert.Equal(t, "", negotiateEncoding("gzip;q=0, identity"))
+}
@@ -12,13 +12,22 @@ import (
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
+// messageTypeOrder — тип сообщения с новым заказом; сообщения без типа тоже считаются заказами
+const messageTypeOrder = "order"
+
 type Consumer struct {
-	consumer         *kafka.Consumer
-	producer         *kafka.Producer
-	saveOrderUseCase *usecase.SaveOrderUseCase
+	consumer            *kafka.Consumer
+	producer            *kafka.Producer
+	saveOrderUseCase    *usecase.SaveOrderUseCase
+	changeStatusUseCase *usecase.ChangeOrderStatusUseCase
+	commandUseCase      *usecase.OrderCommandUseCase
+	// statusTopic — топик событий смены статуса; сообщения остальных топиков считаются заказами
+	statusTopic string
 }
 
-func NewConsumer(brokers, groupID string, saveUseCase *usecase.SaveOrderUseCase) (*Consumer, error) {
+func NewConsumer(brokers, groupID string, saveUseCase *usecase.SaveOrderUseCase,
+	changeStatusUseCase *usecase.ChangeOrderStatusUseCase,
+	commandUseCase *usecase.OrderCommandUseCase) (*Consumer, error) {
 	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
 		"bootstrap.servers":  brokers,
 		"group.id":           groupID,
@@ -39,14 +48,22 @@ func NewConsumer(brokers, groupID string, saveUseCase *usecase.SaveOrderUseCase)
 		return nil, err
 	}
 	return &Consumer{
-		consumer:         consumer,
-		producer:         producer,
-		saveOrderUseCase: saveUseCase,
+		consumer:            consumer,
+		producer:            producer,
+		saveOrderUseCase:    saveUseCase,
+		changeStatusUseCase: changeStatusUseCase,
+		commandUseCase:      commandUseCase,
 	}, nil
 }
 
-func (c *Consumer) Subscribe(topic string) error {
-	return c.consumer.Subscribe(topic, nil)
+// Subscribe подписывается на топик заказов и, если statusTopic не пуст, на топик событий смены статуса
+func (c *Consumer) Subscribe(orderTopic, statusTopic string) error {
+	topics := []string{orderTopic}
+	if statusTopic != "" {
+		topics = append(topics, statusTopic)
+	}
+	c.statusTopic = statusTopic
+	return c.consumer.SubscribeTopics(topics, nil)
 }
 
 func (c *Consumer) Close() {
@@ -81,32 +98,121 @@ func (c *Consumer) Consume(ctx context.Context) {
 				log.Printf("Consumer error: %v (%v)\n", err, msg)
 				continue
 			}
-			var order domain.Order
-			if err := json.Unmarshal(msg.Value, &order); err != nil {
-				log.Printf("Failed to parse message: %v\n", err)
-				c.sendToDLQ(msg, err)
-				c.commitMessage(msg)
+			if c.statusTopic != "" && *msg.TopicPartition.Topic == c.statusTopic {
+				c.handleStatus(ctx, msg)
 				continue
 			}
-			if err := c.saveOrderUseCase.Save(&order); err != nil {
-				if errors.Is(err, domain.OrderAlreadyExistsError) {
-					c.commitMessage(msg)
-					continue
-				}
-				log.Printf("Failed to save order: %v\n", err)
-				continue
+			switch kind := messageType(msg); kind {
+			case "", messageTypeOrder:
+				c.handleOrder(ctx, msg)
+			default:
+				c.handleCommand(ctx, msg, domain.CommandType(kind))
 			}
+		}
+
+	}
+}
+
+func (c *Consumer) handleOrder(ctx context.Context, msg *kafka.Message) {
+	var order domain.Order
+	if err := json.Unmarshal(msg.Value, &order); err != nil {
+		log.Printf("Failed to parse message: %v\n", err)
+		c.sendToDLQ(msg, err)
+		c.commitMessage(msg)
+		return
+	}
+	if err := c.saveOrderUseCase.Save(ctx, &order); err != nil {
+		if errors.Is(err, domain.OrderAlreadyExistsError) {
+			c.commitMessage(msg)
+			return
+		}
+		if errors.Is(err, domain.OrderInvalidError) {
+			log.Printf("Invalid order %s: %v\n", order.OrderUID, err)
+			c.sendToDLQ(msg, err)
+			c.commitMessage(msg)
+			return
+		}
+		if errors.Is(err, domain.ConflictError) {
+			// Повтор не поможет: заказ противоречит уже сохранённым данным
+			log.Printf("Conflicting order %s: %v\n", order.OrderUID, err)
+			c.sendToDLQ(msg, err)
+			c.commitMessage(msg)
+			return
+		}
+		log.Printf("Failed to save order: %v\n", err)
+		return
+	}
+	c.commitMessage(msg)
+	log.Printf("Successfully processed order: %s\n", order.OrderUID)
+}
+
+func (c *Consumer) handleStatus(ctx context.Context, msg *kafka.Message) {
+	var event domain.StatusEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		log.Printf("Failed to parse status event: %v\n", err)
+		c.sendToDLQ(msg, err)
+		c.commitMessage(msg)
+		return
+	}
+	if err := c.changeStatusUseCase.ChangeStatus(ctx, event); err != nil {
+		// Недопустимый переход, неизвестный статус или заказ не исправятся повтором
+		if errors.Is(err, domain.OrderInvalidError) || errors.Is(err, domain.NotFoundError) ||
+			errors.Is(err, domain.ConflictError) {
+			log.Printf("Rejected status event for order %s: %v\n", event.OrderUID, err)
+			c.sendToDLQ(msg, err)
 			c.commitMessage(msg)
-			log.Printf("Successfully processed order: %s\n", order.OrderUID)
+			return
+		}
+		log.Printf("Failed to change order status: %v\n", err)
+		return
+	}
+	c.commitMessage(msg)
+	log.Printf("Order %s moved to status %s\n", event.OrderUID, event.Status)
+}
+
+// messageType берёт тип сообщения из заголовка type, а без него — из поля type конверта
+func messageType(msg *kafka.Message) string {
+	for _, header := range msg.Headers {
+		if header.Key == "type" {
+			return string(header.Value)
 		}
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(msg.Value, &envelope)
+	return envelope.Type
+}
 
+func (c *Consumer) handleCommand(ctx context.Context, msg *kafka.Message, kind domain.CommandType) {
+	var command domain.OrderCommand
+	if err := json.Unmarshal(msg.Value, &command); err != nil {
+		log.Printf("Failed to parse %s command: %v\n", kind, err)
+		c.sendToDLQ(msg, err)
+		c.commitMessage(msg)
+		return
+	}
+	command.Type = kind
+	if err := c.commandUseCase.Apply(ctx, command); err != nil {
+		// Неизвестный заказ, некорректная команда или устаревшая версия не исправятся повтором
+		if errors.Is(err, domain.OrderInvalidError) || errors.Is(err, domain.NotFoundError) ||
+			errors.Is(err, domain.ConflictError) {
+			log.Printf("Rejected %s command for order %s: %v\n", kind, command.OrderUID, err)
+			c.sendToDLQ(msg, err)
+			c.commitMessage(msg)
+			return
+		}
+		log.Printf("Failed to apply %s command: %v\n", kind, err)
+		return
 	}
+	c.commitMessage(msg)
+	log.Printf("Applied %s command to order %s\n", kind, command.OrderUID)
 }
 
-func (c *Consumer) sendToDLQ(msg *kafka.Message, parseErr error) {
+func (c *Consumer) sendToDLQ(msg *kafka.Message, reason error) {
 	dlqMessage := map[string]interface{}{
 		"original_message": string(msg.Value),
-		"error":            parseErr.Error(),
+		"error":            reason.Error(),
 		"timestamp":        time.Now().Format(time.RFC3339),
 		"topic":            *msg.TopicPartition.Topic,
 		"partition":        msg.TopicPartition.Partition,
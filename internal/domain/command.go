@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// CommandType — вид команды изменения сохранённого заказа
+type CommandType string
+
+const (
+	CommandCancelOrder           CommandType = "cancel_order"
+	CommandUpdateDeliveryAddress CommandType = "update_delivery_address"
+	CommandAddItem               CommandType = "add_item"
+	CommandRemoveItem            CommandType = "remove_item"
+	CommandRecordPayment         CommandType = "record_payment"
+)
+
+var OrderVersionMismatchError error = &categorizedError{msg: "order version mismatch", category: ConflictError}
+var OrderNotEditableError error = &categorizedError{msg: "order can no longer be changed", category: ConflictError}
+
+// DeliveryAddress — часть доставки, которую можно поменять после создания заказа
+type DeliveryAddress struct {
+	Zip     string `json:"zip"`
+	City    string `json:"city"`
+	Address string `json:"address"`
+	Region  string `json:"region"`
+}
+
+// OrderCommand — команда изменения заказа. Какие поля нужны, зависит от Type:
+// Address — для update_delivery_address, Item — для add_item, Rid — для remove_item,
+// Operation — для record_payment.
+type OrderCommand struct {
+	Type     CommandType `json:"type"`
+	OrderUID string      `json:"order_uid"`
+	// ExpectedVersion — версия заказа, которую видел отправитель; 0 применяет команду к любой версии
+	ExpectedVersion int              `json:"expected_version,omitempty"`
+	Address         *DeliveryAddress `json:"address,omitempty"`
+	Item            *Item            `json:"item,omitempty"`
+	Rid             string           `json:"rid,omitempty"`
+	// Operation — новая операция по оплате или новый статус уже записанной (по её transaction)
+	Operation *PaymentOperation `json:"operation,omitempty"`
+	// IssuedAt — время команды у отправителя; если не задано, берётся время обработки
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// Validate проверяет, что у команды известный тип и заполнены нужные ему поля
+func (c *OrderCommand) Validate() error {
+	v := &validator{}
+	v.required("order_uid", c.OrderUID, 50)
+	if c.ExpectedVersion < 0 {
+		v.add("expected_version", "must not be negative")
+	}
+	switch c.Type {
+	case CommandCancelOrder:
+	case CommandUpdateDeliveryAddress:
+		if c.Address == nil {
+			v.add("address", "is required")
+			break
+		}
+		v.required("address.zip", c.Address.Zip, 20)
+		v.required("address.city", c.Address.City, 100)
+		v.required("address.address", c.Address.Address, 200)
+		v.required("address.region", c.Address.Region, 100)
+	case CommandAddItem:
+		if c.Item == nil {
+			v.add("item", "is required")
+			break
+		}
+		// track_number сверяется с заказом при применении команды
+		v.item("item.", *c.Item, c.Item.TrackNumber)
+	case CommandRemoveItem:
+		v.required("rid", c.Rid, 50)
+	case CommandRecordPayment:
+		if c.Operation == nil {
+			v.add("operation", "is required")
+			break
+		}
+		// валюта сверяется с оплатой заказа при применении команды
+		if !c.Operation.Currency.Valid() {
+			v.add("operation.currency", "must be a supported ISO 4217 currency code")
+		}
+		v.paymentOperation("operation.", *c.Operation, c.Operation.Currency)
+	default:
+		v.add("type", fmt.Sprintf("unknown command type %q", c.Type))
+	}
+	return v.result()
+}
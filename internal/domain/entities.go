@@ -17,6 +17,13 @@ type Order struct {
 	SmID              int       `json:"sm_id"`
 	DateCreated       time.Time `json:"date_created"`
 	OofShard          string    `json:"oof_shard"`
+	// Status и StatusHistory ведёт сервис: при создании заказа значения из запроса игнорируются
+	Status        OrderStatus    `json:"status"`
+	StatusHistory []StatusChange `json:"status_history,omitempty"`
+	// Version растёт с каждым изменением сохранённого заказа; новый заказ получает версию 1
+	Version int `json:"version"`
+	// UpdatedAt — время последнего изменения заказа в сервисе, в отличие от DateCreated его задаёт не отправитель
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Delivery struct {
@@ -31,30 +38,102 @@ type Delivery struct {
 	Email    string `json:"email"`
 }
 
+// Payment — счёт заказа: сколько и в какой валюте нужно заплатить. Все суммы — в минимальных единицах
+// валюты Currency. Сами списания и возвраты — в Operations.
 type Payment struct {
-	ID           string `json:"-"`
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDt    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	ID           string     `json:"-"`
+	OrderUID     string     `json:"-"`
+	Transaction  string     `json:"transaction"`
+	RequestID    string     `json:"request_id"`
+	Currency     Currency   `json:"currency"`
+	Provider     string     `json:"provider"`
+	Amount       MinorUnits `json:"amount"`
+	PaymentDt    int64      `json:"payment_dt"`
+	Bank         string     `json:"bank"`
+	DeliveryCost MinorUnits `json:"delivery_cost"`
+	GoodsTotal   MinorUnits `json:"goods_total"`
+	CustomFee    MinorUnits `json:"custom_fee"`
+	// Operations — списания и возвраты по заказу в порядке проведения
+	Operations []PaymentOperation `json:"operations,omitempty"`
+	// Totals считается по Operations при чтении заказа и не хранится
+	Totals *PaymentTotals `json:"totals,omitempty"`
+	// Reporting — суммы в валюте отчётности; не хранится, заполняется при выдаче заказа
+	Reporting *ReportingAmounts `json:"reporting,omitempty"`
+}
+
+// ReportingAmounts — суммы оплаты, пересчитанные в валюту отчётности по курсам из файла.
+// Каждая сумма округляется отдельно, поэтому их сумма может разойтись с Amount на единицу.
+type ReportingAmounts struct {
+	Currency     Currency   `json:"currency"`
+	Amount       MinorUnits `json:"amount"`
+	DeliveryCost MinorUnits `json:"delivery_cost"`
+	GoodsTotal   MinorUnits `json:"goods_total"`
+	CustomFee    MinorUnits `json:"custom_fee"`
+}
+
+// Money возвращает сумму в валюте оплаты
+func (p *Payment) Money(amount MinorUnits) Money {
+	return Money{Amount: amount, Currency: p.Currency}
+}
+
+// ConvertTo пересчитывает суммы оплаты в валюту to
+func (p *Payment) ConvertTo(rates *ExchangeRates, to Currency) (*ReportingAmounts, error) {
+	result := &ReportingAmounts{Currency: to}
+	for _, field := range []struct {
+		from MinorUnits
+		to   *MinorUnits
+	}{
+		{p.Amount, &result.Amount},
+		{p.DeliveryCost, &result.DeliveryCost},
+		{p.GoodsTotal, &result.GoodsTotal},
+		{p.CustomFee, &result.CustomFee},
+	} {
+		converted, err := rates.Convert(p.Money(field.from), to)
+		if err != nil {
+			return nil, err
+		}
+		*field.to = converted.Amount
+	}
+	return result, nil
 }
 
 type Item struct {
-	Rid         string `json:"rid"`
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	Rid         string     `json:"rid"`
+	ChrtID      int        `json:"chrt_id"`
+	TrackNumber string     `json:"track_number"`
+	Price       MinorUnits `json:"price"`
+	Name        string     `json:"name"`
+	Sale        int        `json:"sale"`
+	Size        string     `json:"size"`
+	TotalPrice  MinorUnits `json:"total_price"`
+	NmID        int        `json:"nm_id"`
+	Brand       string     `json:"brand"`
+	// Status — код состояния товара во внешней системе, не связан со статусом заказа
+	Status int `json:"status"`
+}
+
+// Clone возвращает глубокую копию заказа, не разделяющую с оригиналом срезы и итоги оплаты
+func (o *Order) Clone() *Order {
+	clone := *o
+	if o.Payment.Operations != nil {
+		clone.Payment.Operations = make([]PaymentOperation, len(o.Payment.Operations))
+		copy(clone.Payment.Operations, o.Payment.Operations)
+	}
+	if o.Payment.Totals != nil {
+		totals := *o.Payment.Totals
+		clone.Payment.Totals = &totals
+	}
+	if o.Payment.Reporting != nil {
+		reporting := *o.Payment.Reporting
+		clone.Payment.Reporting = &reporting
+	}
+	if o.Items != nil {
+		clone.Items = make([]Item, len(o.Items))
+		copy(clone.Items, o.Items)
+	}
+	if o.StatusHistory != nil {
+		clone.StatusHistory = make([]StatusChange, len(o.StatusHistory))
+		copy(clone.StatusHistory, o.StatusHistory)
+	}
+	return &clone
 }
@@ -1,6 +1,54 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
-var OrderNotFoundError = errors.New("order not found")
-var OrderAlreadyExistsError = errors.New("order already exists")
+// Общие категории ошибок: по ним вызывающий решает, что делать, не разбирая конкретную причину
+var (
+	// NotFoundError — запрошенных данных нет
+	NotFoundError = errors.New("not found")
+	// ConflictError — данные противоречат уже сохранённым (дубликат, нарушение ссылочной целостности)
+	ConflictError = errors.New("conflict")
+	// RetryableError — временный сбой, операцию можно повторить
+	RetryableError = errors.New("temporary failure, retry later")
+)
+
+var OrderNotFoundError error = &categorizedError{msg: "order not found", category: NotFoundError}
+var OrderAlreadyExistsError error = &categorizedError{msg: "order already exists", category: ConflictError}
+
+// StorageOverloadedError — БД перегружена, и запрос отклонён, не дожидаясь соединения
+var StorageOverloadedError error = &categorizedError{msg: "storage is overloaded", category: RetryableError}
+var OrderInvalidError = errors.New("order validation failed")
+var AuthenticationFailedError = errors.New("authentication failed")
+var AccessDeniedError = errors.New("access denied")
+
+// categorizedError — конкретная ошибка, которая errors.Is считает ещё и ошибкой своей категории
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string {
+	return e.msg
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.category
+}
+
+// ItemSaveError сообщает, какой из товаров заказа не удалось сохранить
+type ItemSaveError struct {
+	Index int
+	Rid   string
+	Err   error
+}
+
+func (e *ItemSaveError) Error() string {
+	return fmt.Sprintf("item #%d (rid %s): %v", e.Index, e.Rid, e.Err)
+}
+
+func (e *ItemSaveError) Unwrap() error {
+	return e.Err
+}
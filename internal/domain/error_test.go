@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderErrorsBelongToCategories(t *testing.T) {
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", OrderNotFoundError), NotFoundError)
+	assert.ErrorIs(t, OrderAlreadyExistsError, ConflictError)
+	assert.NotErrorIs(t, NotFoundError, OrderNotFoundError)
+	assert.Equal(t, "order not found", OrderNotFoundError.Error())
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+)
+
+var ExchangeRateNotFoundError error = &categorizedError{msg: "exchange rate not found", category: NotFoundError}
+
+// ExchangeRates — курсы валют к базовой: сколько единиц валюты дают за одну единицу базовой.
+// Курсы хранятся точными дробями, чтобы пересчёт не накапливал ошибку округления float.
+type ExchangeRates struct {
+	base  Currency
+	rates map[Currency]*big.Rat
+}
+
+func NewExchangeRates(base Currency, rates map[Currency]*big.Rat) (*ExchangeRates, error) {
+	if !base.Valid() {
+		return nil, fmt.Errorf("unknown base currency %q", base)
+	}
+	copied := make(map[Currency]*big.Rat, len(rates)+1)
+	for currency, rate := range rates {
+		if !currency.Valid() {
+			return nil, fmt.Errorf("unknown currency %q", currency)
+		}
+		if rate == nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", currency)
+		}
+		copied[currency] = new(big.Rat).Set(rate)
+	}
+	copied[base] = big.NewRat(1, 1)
+	return &ExchangeRates{base: base, rates: copied}, nil
+}
+
+func (r *ExchangeRates) Base() Currency {
+	return r.base
+}
+
+// Convert пересчитывает сумму в валюту to через базовую валюту и округляет до минимальной единицы
+// по правилу «половина — от нуля»
+func (r *ExchangeRates) Convert(amount Money, to Currency) (Money, error) {
+	if amount.Currency == to {
+		return amount, nil
+	}
+	fromRate, ok := r.rates[amount.Currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s to %s", ExchangeRateNotFoundError, amount.Currency, r.base)
+	}
+	toRate, ok := r.rates[to]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s to %s", ExchangeRateNotFoundError, r.base, to)
+	}
+
+	value := new(big.Rat).SetInt64(int64(amount.Amount))
+	value.Mul(value, toRate)
+	value.Quo(value, fromRate)
+	shift := to.Exponent() - amount.Currency.Exponent()
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift > 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	rounded := roundHalfAwayFromZero(value)
+	if !rounded.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s in %s", MoneyOverflowError, amount, to)
+	}
+	return Money{Amount: MinorUnits(rounded.Int64()), Currency: to}, nil
+}
+
+func roundHalfAwayFromZero(value *big.Rat) *big.Int {
+	numerator := new(big.Int).Abs(value.Num())
+	quotient, remainder := new(big.Int).QuoRem(numerator, value.Denom(), new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
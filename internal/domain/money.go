@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// Currency — трёхбуквенный код валюты по ISO 4217
+type Currency string
+
+// MinorUnits — сумма в минимальных единицах валюты (копейках, центах); у JPY это сами иены
+type MinorUnits int64
+
+var MoneyOverflowError error = &categorizedError{msg: "money amount overflows", category: OrderInvalidError}
+var CurrencyMismatchError error = &categorizedError{msg: "currencies do not match", category: OrderInvalidError}
+
+// currencyExponents — валюты ISO 4217, которые принимает сервис, и число знаков дробной части у каждой
+var currencyExponents = map[Currency]int{
+	"RUB": 2, "BYN": 2, "KZT": 2, "UZS": 2, "KGS": 2, "AMD": 2, "AZN": 2, "GEL": 2, "UAH": 2,
+	"USD": 2, "EUR": 2, "GBP": 2, "CHF": 2, "PLN": 2, "CZK": 2, "SEK": 2, "NOK": 2, "DKK": 2,
+	"CNY": 2, "HKD": 2, "SGD": 2, "INR": 2, "AED": 2, "TRY": 2, "ILS": 2, "CAD": 2, "AUD": 2,
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+}
+
+// Valid сообщает, известна ли сервису валюта
+func (c Currency) Valid() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent возвращает число знаков дробной части валюты; для неизвестной валюты — 2
+func (c Currency) Exponent() int {
+	if exponent, ok := currencyExponents[c]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Money — сумма в минимальных единицах конкретной валюты. Арифметика не допускает молчаливого
+// переполнения и смешивания валют: такие операции возвращают ошибку.
+type Money struct {
+	Amount   MinorUnits `json:"amount"`
+	Currency Currency   `json:"currency"`
+}
+
+func NewMoney(amount MinorUnits, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", CurrencyMismatchError, m.Currency, other.Currency)
+	}
+	sum, err := addMinor(m.Amount, other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %s - %s", MoneyOverflowError, m, other)
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Sum складывает суммы в одной валюте; пустой список даёт ноль
+func Sum(currency Currency, amounts ...MinorUnits) (Money, error) {
+	total := Money{Currency: currency}
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(Money{Amount: amount, Currency: currency}); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// String форматирует сумму в основных единицах валюты, например "18.17 USD"
+func (m Money) String() string {
+	sign := ""
+	magnitude := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+	exponent := m.Currency.Exponent()
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d %s", sign, magnitude, m.Currency)
+	}
+	scale := uint64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, magnitude/scale, exponent, magnitude%scale, m.Currency)
+}
+
+func addMinor(a, b MinorUnits) (MinorUnits, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, fmt.Errorf("%w: %d + %d", MoneyOverflowError, a, b)
+	}
+	return a + b, nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1500, "USD").Add(NewMoney(317, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1817, "USD"), sum)
+
+	_, err = NewMoney(1, "USD").Add(NewMoney(1, "RUB"))
+	assert.ErrorIs(t, err, CurrencyMismatchError)
+
+	_, err = NewMoney(math.MaxInt64, "USD").Add(NewMoney(1, "USD"))
+	assert.ErrorIs(t, err, MoneyOverflowError)
+	assert.ErrorIs(t, err, OrderInvalidError)
+
+	_, err = NewMoney(math.MinInt64+1, "USD").Sub(NewMoney(2, "USD"))
+	assert.ErrorIs(t, err, MoneyOverflowError)
+
+	_, err = Sum("USD", math.MaxInt64/2, math.MaxInt64/2, 2)
+	assert.ErrorIs(t, err, MoneyOverflowError)
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "18.17 USD", NewMoney(1817, "USD").String())
+	assert.Equal(t, "-0.05 RUB", NewMoney(-5, "RUB").String())
+	assert.Equal(t, "1817 JPY", NewMoney(1817, "JPY").String())
+	assert.Equal(t, "1.817 KWD", NewMoney(1817, "KWD").String())
+}
+
+func TestExchangeRatesConvert(t *testing.T) {
+	rates, err := NewExchangeRates("RUB", map[Currency]*big.Rat{
+		"USD": big.NewRat(1, 80),
+		"JPY": big.NewRat(3, 2),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		amount Money
+		to     Currency
+		want   Money
+	}{
+		{"same currency", NewMoney(1817, "USD"), "USD", NewMoney(1817, "USD")},
+		{"to base", NewMoney(1817, "USD"), "RUB", NewMoney(145360, "RUB")},
+		{"from base rounds half away from zero", NewMoney(40, "RUB"), "USD", NewMoney(1, "USD")},
+		{"negative rounds half away from zero", NewMoney(-40, "RUB"), "USD", NewMoney(-1, "USD")},
+		{"across exponents", NewMoney(1817, "USD"), "JPY", NewMoney(2180, "JPY")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.amount, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = rates.Convert(NewMoney(1, "EUR"), "RUB")
+	assert.ErrorIs(t, err, ExchangeRateNotFoundError)
+}
+
+func TestNewExchangeRatesRejectsBadRates(t *testing.T) {
+	_, err := NewExchangeRates("RUB", map[Currency]*big.Rat{"USD": big.NewRat(0, 1)})
+	assert.Error(t, err)
+	_, err = NewExchangeRates("RUB", map[Currency]*big.Rat{"XYZ": big.NewRat(1, 1)})
+	assert.Error(t, err)
+	_, err = NewExchangeRates("rub", nil)
+	assert.Error(t, err)
+}
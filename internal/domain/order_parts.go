@@ -0,0 +1,17 @@
+package domain
+
+// OrderParts — набор вложенных частей заказа, которые нужно загрузить
+type OrderParts uint8
+
+const (
+	PartDelivery OrderParts = 1 << iota
+	PartPayment
+	PartItems
+	PartStatusHistory
+
+	AllOrderParts = PartDelivery | PartPayment | PartItems | PartStatusHistory
+)
+
+func (p OrderParts) Has(part OrderParts) bool {
+	return p&part == part
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PaymentOperationType — вид операции по оплате заказа
+type PaymentOperationType string
+
+const (
+	PaymentCharge PaymentOperationType = "charge"
+	PaymentRefund PaymentOperationType = "refund"
+)
+
+func (t PaymentOperationType) Valid() bool {
+	return t == PaymentCharge || t == PaymentRefund
+}
+
+// PaymentOperationStatus — состояние операции у платёжного провайдера
+type PaymentOperationStatus string
+
+const (
+	PaymentPending   PaymentOperationStatus = "pending"
+	PaymentSucceeded PaymentOperationStatus = "succeeded"
+	PaymentFailed    PaymentOperationStatus = "failed"
+)
+
+func (s PaymentOperationStatus) Valid() bool {
+	return s == PaymentPending || s == PaymentSucceeded || s == PaymentFailed
+}
+
+var PaymentOperationConflictError error = &categorizedError{msg: "payment operation conflicts with the recorded one",
+	category: ConflictError}
+var RefundExceedsPaidError error = &categorizedError{msg: "refunds exceed the paid amount", category: ConflictError}
+
+// PaymentOperation — списание или возврат по заказу. Transaction — идентификатор операции у провайдера,
+// уникальный в пределах заказа: у заказа может быть несколько списаний (раздельная оплата, повтор после
+// отказа) и несколько частичных возвратов.
+type PaymentOperation struct {
+	Transaction string                 `json:"transaction"`
+	Type        PaymentOperationType   `json:"type"`
+	Status      PaymentOperationStatus `json:"status"`
+	Amount      MinorUnits             `json:"amount"`
+	Currency    Currency               `json:"currency"`
+	Provider    string                 `json:"provider"`
+	PerformedAt time.Time              `json:"performed_at"`
+}
+
+// CheckStatusChange проверяет смену статуса уже записанной операции: pending может завершиться
+// успехом или отказом, завершённая операция больше не меняется
+func (op *PaymentOperation) CheckStatusChange(to PaymentOperationStatus) error {
+	if op.Status != PaymentPending {
+		return fmt.Errorf("%w: operation %s is already %s, cannot become %s", PaymentOperationConflictError,
+			op.Transaction, op.Status, to)
+	}
+	return nil
+}
+
+// PaymentTotals — итог операций по оплате; учитываются только успешные операции.
+// Outstanding = amount − paid + refunded: сколько ещё не оплачено, отрицательное значение — переплата.
+type PaymentTotals struct {
+	Paid        MinorUnits `json:"paid"`
+	Refunded    MinorUnits `json:"refunded"`
+	Outstanding MinorUnits `json:"outstanding"`
+}
+
+// ComputeTotals считает итог операций по оплате в валюте оплаты
+func (p *Payment) ComputeTotals(operations []PaymentOperation) (*PaymentTotals, error) {
+	paid, refunded := p.Money(0), p.Money(0)
+	for _, op := range operations {
+		if op.Status != PaymentSucceeded {
+			continue
+		}
+		var err error
+		amount := Money{Amount: op.Amount, Currency: op.Currency}
+		if op.Type == PaymentRefund {
+			refunded, err = refunded.Add(amount)
+		} else {
+			paid, err = paid.Add(amount)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("payment operation %s: %w", op.Transaction, err)
+		}
+	}
+	net, err := paid.Sub(refunded)
+	if err != nil {
+		return nil, err
+	}
+	outstanding, err := p.Money(p.Amount).Sub(net)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentTotals{Paid: paid.Amount, Refunded: refunded.Amount, Outstanding: outstanding.Amount}, nil
+}
+
+// InitialOperations возвращает операции, с которыми сохраняется новый заказ. Если отправитель не передал
+// их явно, оплата с payment_dt считается одним успешным списанием на всю сумму.
+func (p *Payment) InitialOperations() []PaymentOperation {
+	if len(p.Operations) > 0 || p.PaymentDt <= 0 {
+		return p.Operations
+	}
+	return []PaymentOperation{{
+		Transaction: p.Transaction,
+		Type:        PaymentCharge,
+		Status:      PaymentSucceeded,
+		Amount:      p.Amount,
+		Currency:    p.Currency,
+		Provider:    p.Provider,
+		PerformedAt: time.Unix(p.PaymentDt, 0).UTC(),
+	}}
+}
+
+func (v *validator) paymentOperation(prefix string, op PaymentOperation, currency Currency) {
+	v.required(prefix+"transaction", op.Transaction, 50)
+	if !op.Type.Valid() {
+		v.add(prefix+"type", "must be charge or refund")
+	}
+	if !op.Status.Valid() {
+		v.add(prefix+"status", "must be pending, succeeded or failed")
+	}
+	if op.Amount <= 0 {
+		v.add(prefix+"amount", "must be positive")
+	}
+	if op.Currency != currency {
+		v.add(prefix+"currency", "must match payment currency")
+	}
+	v.maxLen(prefix+"provider", op.Provider, 50)
+	if op.PerformedAt.IsZero() {
+		v.add(prefix+"performed_at", "is required")
+	}
+}
+
+// paymentOperations проверяет операции нового заказа: уникальность transaction и то, что возвраты
+// не превышают успешные списания
+func (v *validator) paymentOperations(p *Payment) {
+	before := len(v.violations)
+	seen := make(map[string]bool, len(p.Operations))
+	for i, op := range p.Operations {
+		prefix := fmt.Sprintf("payment.operations[%d].", i)
+		v.paymentOperation(prefix, op, p.Currency)
+		if seen[op.Transaction] {
+			v.add(prefix+"transaction", "must be unique within the order")
+		}
+		seen[op.Transaction] = true
+	}
+	if len(v.violations) > before {
+		return
+	}
+	totals, err := p.ComputeTotals(p.Operations)
+	if err != nil {
+		v.add("payment.operations", "amounts overflow")
+		return
+	}
+	if totals.Refunded > totals.Paid {
+		v.add("payment.operations", "refunds must not exceed succeeded charges")
+	}
+}
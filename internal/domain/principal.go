@@ -0,0 +1,65 @@
+package domain
+
+import "strings"
+
+type Role string
+
+const (
+	// RoleSupport видит заказы целиком, включая персональные данные покупателя
+	RoleSupport Role = "support"
+	// RoleAnalytics видит все заказы, но телефон, email и адрес маскируются
+	RoleAnalytics Role = "analytics"
+	// RolePartner видит и создаёт только заказы своей службы доставки
+	RolePartner Role = "partner"
+	// RoleAdmin управляет кешем сервиса, но не читает и не создаёт заказы
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleSupport, RoleAnalytics, RolePartner, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Principal — аутентифицированный клиент API
+type Principal struct {
+	Subject         string
+	Role            Role
+	DeliveryService string
+}
+
+func (p *Principal) CanView(order *Order) bool {
+	return p.CanViewDeliveryService(order.DeliveryService)
+}
+
+// CanViewDeliveryService проверяет доступ к заказам службы доставки, когда самого заказа под рукой нет
+func (p *Principal) CanViewDeliveryService(deliveryService string) bool {
+	switch p.Role {
+	case RoleSupport, RoleAnalytics:
+		return true
+	case RolePartner:
+		return p.DeliveryService != "" && strings.EqualFold(p.DeliveryService, deliveryService)
+	}
+	return false
+}
+
+func (p *Principal) CanCreate(order *Order) bool {
+	switch p.Role {
+	case RoleSupport:
+		return true
+	case RolePartner:
+		return p.CanView(order)
+	}
+	return false
+}
+
+// SeesFullPII сообщает, можно ли показывать персональные данные без маскирования
+func (p *Principal) SeesFullPII() bool {
+	return p.Role == RoleSupport || p.Role == RolePartner
+}
+
+func (p *Principal) CanAdministerCache() bool {
+	return p.Role == RoleAdmin
+}
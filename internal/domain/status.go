@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderStatus — этап жизненного цикла заказа
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "created"
+	StatusPaid       OrderStatus = "paid"
+	StatusAssembling OrderStatus = "assembling"
+	StatusShipped    OrderStatus = "shipped"
+	StatusDelivered  OrderStatus = "delivered"
+	StatusCancelled  OrderStatus = "cancelled"
+	StatusReturned   OrderStatus = "returned"
+)
+
+// statusTransitions — допустимые переходы: отменить можно только не отправленный заказ,
+// вернуть — отправленный или доставленный. cancelled и returned — конечные статусы.
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+	StatusCancelled:  nil,
+	StatusReturned:   nil,
+}
+
+var InvalidStatusError = fmt.Errorf("%w: unknown order status", OrderInvalidError)
+var InvalidStatusTransitionError error = &categorizedError{msg: "invalid order status transition", category: ConflictError}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition возвращает InvalidStatusTransitionError, если из статуса s нельзя перейти в next
+func (s OrderStatus) CheckTransition(next OrderStatus) error {
+	if !next.Valid() {
+		return fmt.Errorf("%w %q", InvalidStatusError, next)
+	}
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", InvalidStatusTransitionError, s, next)
+	}
+	return nil
+}
+
+// StatusChange — запись истории статусов заказа
+type StatusChange struct {
+	Status    OrderStatus `json:"status"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// StatusEvent — сообщение о смене статуса заказа из топика статусов
+type StatusEvent struct {
+	OrderUID string      `json:"order_uid"`
+	Status   OrderStatus `json:"status"`
+	// ChangedAt — время события у отправителя; если не задано, берётся время обработки
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// BeforeShipment сообщает, что заказ ещё не передан в доставку и не отменён
+func (s OrderStatus) BeforeShipment() bool {
+	return s == StatusCreated || s == StatusPaid || s == StatusAssembling
+}
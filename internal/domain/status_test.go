@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		allowed  bool
+	}{
+		{StatusCreated, StatusPaid, true},
+		{StatusCreated, StatusCancelled, true},
+		{StatusPaid, StatusAssembling, true},
+		{StatusAssembling, StatusShipped, true},
+		{StatusShipped, StatusDelivered, true},
+		{StatusShipped, StatusReturned, true},
+		{StatusDelivered, StatusReturned, true},
+		{StatusCreated, StatusShipped, false},
+		{StatusPaid, StatusCreated, false},
+		{StatusShipped, StatusCancelled, false},
+		{StatusCancelled, StatusPaid, false},
+		{StatusReturned, StatusDelivered, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := tt.from.CheckTransition(tt.to)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, InvalidStatusTransitionError)
+			assert.ErrorIs(t, err, ConflictError)
+		})
+	}
+}
+
+func TestCheckTransitionRejectsUnknownStatus(t *testing.T) {
+	err := StatusCreated.CheckTransition("lost")
+	assert.ErrorIs(t, err, OrderInvalidError)
+	assert.False(t, OrderStatus("lost").Valid())
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Violation описывает одно нарушенное правило валидации заказа
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Message)
+	}
+	return fmt.Sprintf("%s: %s", OrderInvalidError, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return OrderInvalidError
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(field, message string) {
+	v.violations = append(v.violations, Violation{Field: field, Message: message})
+}
+
+func (v *validator) required(field, value string, maxLen int) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return
+	}
+	v.maxLen(field, value, maxLen)
+}
+
+func (v *validator) maxLen(field, value string, maxLen int) {
+	if len([]rune(value)) > maxLen {
+		v.add(field, fmt.Sprintf("must be at most %d characters", maxLen))
+	}
+}
+
+func (v *validator) nonNegative(field string, value MinorUnits) {
+	if value < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+// Validate проверяет заказ перед сохранением. Ограничения длин совпадают со схемой БД,
+// чтобы некорректные данные отсекались до обращения к базе.
+func (o *Order) Validate() error {
+	v := &validator{}
+
+	v.required("order_uid", o.OrderUID, 50)
+	v.required("track_number", o.TrackNumber, 50)
+	v.required("entry", o.Entry, 10)
+	v.required("locale", o.Locale, 10)
+	v.maxLen("internal_signature", o.InternalSignature, 100)
+	v.required("customer_id", o.CustomerID, 50)
+	v.required("delivery_service", o.DeliveryService, 50)
+	v.required("shardkey", o.Shardkey, 10)
+	v.required("oof_shard", o.OofShard, 10)
+	if o.DateCreated.IsZero() {
+		v.add("date_created", "is required")
+	}
+
+	d := o.Delivery
+	v.required("delivery.name", d.Name, 100)
+	v.required("delivery.phone", d.Phone, 20)
+	v.required("delivery.zip", d.Zip, 20)
+	v.required("delivery.city", d.City, 100)
+	v.required("delivery.address", d.Address, 200)
+	v.required("delivery.region", d.Region, 100)
+	v.required("delivery.email", d.Email, 100)
+	if d.Email != "" && !strings.Contains(d.Email, "@") {
+		v.add("delivery.email", "must be a valid email address")
+	}
+
+	p := o.Payment
+	v.maxLen("payment.transaction", p.Transaction, 50)
+	v.maxLen("payment.request_id", p.RequestID, 50)
+	v.required("payment.currency", string(p.Currency), 10)
+	if p.Currency != "" && !p.Currency.Valid() {
+		v.add("payment.currency", "must be a supported ISO 4217 currency code")
+	}
+	v.required("payment.provider", p.Provider, 50)
+	v.required("payment.bank", p.Bank, 50)
+	v.nonNegative("payment.amount", p.Amount)
+	v.nonNegative("payment.delivery_cost", p.DeliveryCost)
+	v.nonNegative("payment.goods_total", p.GoodsTotal)
+	v.nonNegative("payment.custom_fee", p.CustomFee)
+	if p.PaymentDt <= 0 {
+		v.add("payment.payment_dt", "is required")
+	}
+
+	if len(o.Items) == 0 {
+		v.add("items", "must contain at least one item")
+	}
+	for i, item := range o.Items {
+		v.item(fmt.Sprintf("items[%d].", i), item, o.TrackNumber)
+	}
+	v.totals(&o.Payment, o.Items)
+	v.paymentOperations(&o.Payment)
+
+	return v.result()
+}
+
+func (v *validator) item(prefix string, item Item, trackNumber string) {
+	v.required(prefix+"rid", item.Rid, 50)
+	if item.TrackNumber != trackNumber {
+		v.add(prefix+"track_number", "must match order track_number")
+	}
+	v.required(prefix+"name", item.Name, 100)
+	v.maxLen(prefix+"size", item.Size, 10)
+	v.required(prefix+"brand", item.Brand, 100)
+	v.nonNegative(prefix+"price", item.Price)
+	v.nonNegative(prefix+"total_price", item.TotalPrice)
+	if item.Sale < 0 || item.Sale > 100 {
+		v.add(prefix+"sale", "must be between 0 and 100")
+	}
+}
+
+// totals сверяет суммы оплаты: goods_total — сумма total_price товаров,
+// amount — goods_total плюс доставка и пошлина
+func (v *validator) totals(p *Payment, items []Item) {
+	prices := make([]MinorUnits, 0, len(items))
+	for _, item := range items {
+		prices = append(prices, item.TotalPrice)
+	}
+	goodsTotal, err := Sum(p.Currency, prices...)
+	if err != nil {
+		v.add("items", "total_price sum overflows")
+		return
+	}
+	if goodsTotal.Amount != p.GoodsTotal {
+		v.add("payment.goods_total", fmt.Sprintf("must equal the sum of items total_price (%d)", goodsTotal.Amount))
+	}
+	amount, err := Sum(p.Currency, p.GoodsTotal, p.DeliveryCost, p.CustomFee)
+	if err != nil {
+		v.add("payment.amount", "goods_total + delivery_cost + custom_fee overflows")
+		return
+	}
+	if amount.Amount != p.Amount {
+		v.add("payment.amount", fmt.Sprintf("must equal goods_total + delivery_cost + custom_fee (%d)", amount.Amount))
+	}
+}
+
+func (v *validator) result() error {
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
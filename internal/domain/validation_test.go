@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validOrder() *Order {
+	return &Order{
+		OrderUID:        "b563feb7b2b84b6test",
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:        "1",
+		Delivery: Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809",
+			City: "Kiryat Mozkin", Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay",
+			Amount: 1817, PaymentDt: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []Item{{
+			ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest",
+			Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202,
+		}},
+	}
+}
+
+func TestValidateAcceptsValidOrder(t *testing.T) {
+	assert.NoError(t, validOrder().Validate())
+}
+
+func TestValidateCollectsViolations(t *testing.T) {
+	order := validOrder()
+	order.OrderUID = ""
+	order.Delivery.Email = "not-an-email"
+	order.Items[0].TrackNumber = "OTHER"
+
+	err := order.Validate()
+
+	assert.True(t, errors.Is(err, OrderInvalidError))
+	var validationErr *ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		fields := make([]string, 0, len(validationErr.Violations))
+		for _, v := range validationErr.Violations {
+			fields = append(fields, v.Field)
+		}
+		assert.ElementsMatch(t,
+			[]string{"order_uid", "delivery.email", "items[0].track_number"}, fields)
+	}
+}
+
+func TestValidateChecksPaymentTotals(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(o *Order)
+		wantField string
+	}{
+		{"goods total differs from items", func(o *Order) { o.Payment.GoodsTotal = 417; o.Payment.Amount = 1917 },
+			"payment.goods_total"},
+		{"amount differs from parts", func(o *Order) { o.Payment.CustomFee = 10 }, "payment.amount"},
+		{"unknown currency", func(o *Order) { o.Payment.Currency = "usd" }, "payment.currency"},
+		{"items total overflows", func(o *Order) {
+			o.Items = append(o.Items, o.Items[0])
+			o.Items[0].TotalPrice, o.Items[1].TotalPrice = math.MaxInt64, 1
+		}, "items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := validOrder()
+			tt.change(order)
+
+			var validationErr *ValidationError
+			if assert.True(t, errors.As(order.Validate(), &validationErr)) {
+				assert.Equal(t, tt.wantField, validationErr.Violations[0].Field)
+			}
+		})
+	}
+}
+
+func TestValidateChecksPaymentOperations(t *testing.T) {
+	performed := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	charge := PaymentOperation{Transaction: "charge-1", Type: PaymentCharge, Status: PaymentSucceeded,
+		Amount: 1817, Currency: "USD", PerformedAt: performed}
+
+	order := validOrder()
+	order.Payment.Operations = []PaymentOperation{charge,
+		{Transaction: "refund-1", Type: PaymentRefund, Status: PaymentSucceeded, Amount: 317, Currency: "USD",
+			PerformedAt: performed}}
+	assert.NoError(t, order.Validate())
+
+	order.Payment.Operations[1].Amount = 2000
+	var verr *ValidationError
+	assert.True(t, errors.As(order.Validate(), &verr))
+	assert.Equal(t, []Violation{{Field: "payment.operations", Message: "refunds must not exceed succeeded charges"}},
+		verr.Violations)
+
+	order.Payment.Operations = []PaymentOperation{charge, charge}
+	order.Payment.Operations[1].Currency = "EUR"
+	assert.True(t, errors.As(order.Validate(), &verr))
+	assert.Equal(t, []Violation{
+		{Field: "payment.operations[1].currency", Message: "must match payment currency"},
+		{Field: "payment.operations[1].transaction", Message: "must be unique within the order"},
+	}, verr.Violations)
+}
+
+func TestPaymentTotals(t *testing.T) {
+	payment := validOrder().Payment
+	operations := []PaymentOperation{
+		{Transaction: "1", Type: PaymentCharge, Status: PaymentFailed, Amount: 1817, Currency: "USD"},
+		{Transaction: "2", Type: PaymentCharge, Status: PaymentSucceeded, Amount: 1000, Currency: "USD"},
+		{Transaction: "3", Type: PaymentCharge, Status: PaymentPending, Amount: 817, Currency: "USD"},
+		{Transaction: "4", Type: PaymentRefund, Status: PaymentSucceeded, Amount: 200, Currency: "USD"},
+	}
+	totals, err := payment.ComputeTotals(operations)
+	assert.NoError(t, err)
+	assert.Equal(t, &PaymentTotals{Paid: 1000, Refunded: 200, Outstanding: 1017}, totals)
+
+	initial := payment.InitialOperations()
+	assert.Equal(t, []PaymentOperation{{Transaction: payment.Transaction, Type: PaymentCharge,
+		Status: PaymentSucceeded, Amount: 1817, Currency: "USD", Provider: "wbpay",
+		PerformedAt: time.Unix(1637907727, 0).UTC()}}, initial)
+	payment.PaymentDt = 0
+	assert.Empty(t, payment.InitialOperations())
+}
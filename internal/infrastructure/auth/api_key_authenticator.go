@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"web_service/internal/domain"
+)
+
+// apiKeyEntry — запись файла ключей. В файле хранится только SHA-256 ключа, а не сам ключ.
+type apiKeyEntry struct {
+	KeySHA256       string      `json:"key_sha256"`
+	Subject         string      `json:"subject"`
+	Role            domain.Role `json:"role"`
+	DeliveryService string      `json:"delivery_service"`
+}
+
+type APIKeyAuthenticator struct {
+	keys []apiKeyEntry
+}
+
+// NewAPIKeyAuthenticatorFromFile читает JSON-массив ключей из файла
+func NewAPIKeyAuthenticatorFromFile(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read api keys file: %w", err)
+	}
+	var entries []apiKeyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse api keys file: %w", err)
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.KeySHA256 = strings.ToLower(entry.KeySHA256)
+		if len(entry.KeySHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("api key %d: key_sha256 must be a hex-encoded SHA-256", i)
+		}
+		if !entry.Role.Valid() {
+			return nil, fmt.Errorf("api key %d: unknown role %q", i, entry.Role)
+		}
+		if entry.Role == domain.RolePartner && entry.DeliveryService == "" {
+			return nil, fmt.Errorf("api key %d: partner key requires delivery_service", i)
+		}
+	}
+	return &APIKeyAuthenticator{keys: entries}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, credential string) (*domain.Principal, error) {
+	sum := sha256.Sum256([]byte(credential))
+	hash := hex.EncodeToString(sum[:])
+	for _, entry := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(entry.KeySHA256)) == 1 {
+			return &domain.Principal{
+				Subject:         entry.Subject,
+				Role:            entry.Role,
+				DeliveryService: entry.DeliveryService,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown api key", domain.AuthenticationFailedError)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"web_service/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type orderClaims struct {
+	Role            domain.Role `json:"role"`
+	DeliveryService string      `json:"delivery_service"`
+	jwt.RegisteredClaims
+}
+
+// JWTAuthenticator проверяет подпись токенов по ключам из локального JWKS-файла
+type JWTAuthenticator struct {
+	keys   map[string]crypto.PublicKey
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticatorFromFile(jwksPath, issuer, audience string) (*JWTAuthenticator, error) {
+	keys, err := loadJWKS(jwksPath)
+	if err != nil {
+		return nil, err
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return &JWTAuthenticator{keys: keys, parser: jwt.NewParser(options...)}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(_ context.Context, credential string) (*domain.Principal, error) {
+	var claims orderClaims
+	_, err := a.parser.ParseWithClaims(credential, &claims, a.keyFor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.AuthenticationFailedError, err)
+	}
+	if !claims.Role.Valid() {
+		return nil, fmt.Errorf("%w: token has unknown role %q", domain.AuthenticationFailedError, claims.Role)
+	}
+	return &domain.Principal{
+		Subject:         claims.Subject,
+		Role:            claims.Role,
+		DeliveryService: claims.DeliveryService,
+	}, nil
+}
+
+func (a *JWTAuthenticator) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJWKS(t *testing.T, key *ecdsa.PrivateKey) string {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","kid":"test","use":"sig","crv":"P-256","x":%q,"y":%q}]}`,
+		encode(key.X.FillBytes(make([]byte, 32))), encode(key.Y.FillBytes(make([]byte, 32))))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(jwks), 0o600))
+	return path
+}
+
+func signToken(t *testing.T, key *ecdsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTAuthenticatorAcceptsSignedToken(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	authenticator, err := NewJWTAuthenticatorFromFile(writeJWKS(t, key), "issuer", "")
+	require.NoError(t, err)
+
+	principal, err := authenticator.Authenticate(context.Background(), signToken(t, key, jwt.MapClaims{
+		"sub": "partner-1", "iss": "issuer", "role": "partner", "delivery_service": "dhl",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Principal{Subject: "partner-1", Role: domain.RolePartner, DeliveryService: "dhl"}, principal)
+}
+
+func TestJWTAuthenticatorRejectsForeignAndExpiredTokens(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	authenticator, err := NewJWTAuthenticatorFromFile(writeJWKS(t, key), "", "")
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"foreign key": signToken(t, other, jwt.MapClaims{"role": "support", "exp": time.Now().Add(time.Minute).Unix()}),
+		"expired":     signToken(t, key, jwt.MapClaims{"role": "support", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"no role":     signToken(t, key, jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix()}),
+	} {
+		_, err := authenticator.Authenticate(context.Background(), token)
+		assert.True(t, errors.Is(err, domain.AuthenticationFailedError), name)
+	}
+}
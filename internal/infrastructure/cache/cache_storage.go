@@ -3,11 +3,28 @@ package cache
 import (
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 	"web_service/internal/domain"
+	"web_service/internal/protocols"
 )
 
+// localEntry — заказ вместе с закодированными представлениями, построенными по нему.
+// Каждое сохранение заказа создаёт новую запись, поэтому старые представления уходят вместе с ней.
+type localEntry struct {
+	order           *domain.Order
+	generation      uint64
+	savedAt         time.Time
+	representations sync.Map
+}
+
+// LocalOrderStorage хранит собственные копии заказов и отдаёт каждому вызывающему новую копию,
+// поэтому изменение полученного заказа не портит кеш для остальных
 type LocalOrderStorage struct {
-	data sync.Map
+	data        sync.Map
+	generations atomic.Uint64
+	hits        atomic.Uint64
+	misses      atomic.Uint64
 }
 
 func NewLocalOrderStorage() *LocalOrderStorage {
@@ -15,21 +32,112 @@ func NewLocalOrderStorage() *LocalOrderStorage {
 }
 
 func (s *LocalOrderStorage) Get(orderUID string) (*domain.Order, error) {
-	val, ok := s.data.Load(orderUID)
+	entry, ok := s.load(orderUID)
 	if !ok {
+		s.misses.Add(1)
 		log.Printf("order %s not found in cache\n", orderUID)
 		return nil, domain.OrderNotFoundError
 	}
-	order, ok := val.(*domain.Order)
-	if !ok {
-		log.Printf("order %s not found in cache: could not serialize to domain entity\n", orderUID)
-		return nil, domain.OrderNotFoundError
-	}
+	s.hits.Add(1)
 	log.Printf("found order %s in cache\n", orderUID)
-	return order, nil
+	return entry.order.Clone(), nil
 }
 
 func (s *LocalOrderStorage) Save(orderUID string, order *domain.Order) {
-	s.data.Store(orderUID, order)
+	s.data.Store(orderUID, s.newEntry(order))
 	log.Printf("saved order %s in cache\n", orderUID)
 }
+
+func (s *LocalOrderStorage) SaveAll(orders []*domain.Order) {
+	for _, order := range orders {
+		s.data.Store(order.OrderUID, s.newEntry(order))
+	}
+	log.Printf("saved %d orders in cache\n", len(orders))
+}
+
+func (s *LocalOrderStorage) Delete(orderUID string) {
+	s.data.Delete(orderUID)
+	log.Printf("deleted order %s from cache\n", orderUID)
+}
+
+// Range отдаёт fn сами хранимые заказы без копирования; fn не должен их изменять
+func (s *LocalOrderStorage) Range(fn func(order *domain.Order) bool) {
+	s.data.Range(func(_, value any) bool {
+		entry, ok := value.(*localEntry)
+		if !ok {
+			return true
+		}
+		return fn(entry.order)
+	})
+}
+
+func (s *LocalOrderStorage) GetRepresentation(orderUID, variant string) (*protocols.Representation, uint64, bool) {
+	entry, ok := s.load(orderUID)
+	if !ok {
+		return nil, 0, false
+	}
+	// Промах по представлению не считаем: вызывающий после него читает заказ через Get
+	if value, ok := entry.representations.Load(variant); ok {
+		s.hits.Add(1)
+		return value.(*protocols.Representation), entry.generation, true
+	}
+	return nil, entry.generation, false
+}
+
+func (s *LocalOrderStorage) SaveRepresentation(orderUID, variant string, generation uint64,
+	representation *protocols.Representation) {
+	entry, ok := s.load(orderUID)
+	if !ok || entry.generation != generation {
+		return
+	}
+	entry.representations.Store(variant, representation)
+}
+
+// Stats обходит весь кеш, поэтому предназначен для редких запросов администратора
+func (s *LocalOrderStorage) Stats() protocols.CacheStats {
+	stats := protocols.CacheStats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+	s.data.Range(func(_, value any) bool {
+		entry, ok := value.(*localEntry)
+		if !ok {
+			return true
+		}
+		stats.Entries++
+		stats.Bytes += approxOrderSize(entry.order)
+		entry.representations.Range(func(_, representation any) bool {
+			stats.Bytes += int64(len(representation.(*protocols.Representation).Body))
+			return true
+		})
+		if stats.OldestEntry.IsZero() || entry.savedAt.Before(stats.OldestEntry) {
+			stats.OldestEntry = entry.savedAt
+		}
+		return true
+	})
+	return stats
+}
+
+func (s *LocalOrderStorage) Keys(after string, limit int) []string {
+	var keys []string
+	s.data.Range(func(key, _ any) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+	return pageKeys(keys, after, limit)
+}
+
+func (s *LocalOrderStorage) Flush() {
+	s.data.Clear()
+	log.Println("flushed order cache")
+}
+
+func (s *LocalOrderStorage) load(orderUID string) (*localEntry, bool) {
+	value, ok := s.data.Load(orderUID)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := value.(*localEntry)
+	return entry, ok
+}
+
+func (s *LocalOrderStorage) newEntry(order *domain.Order) *localEntry {
+	return &localEntry{order: order.Clone(), generation: s.generations.Add(1), savedAt: time.Now()}
+}
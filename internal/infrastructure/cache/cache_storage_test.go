@@ -44,3 +44,91 @@ func TestPutAndGetAsync(t *testing.T) {
 		assert.Equal(t, id, actual.OrderUID)
 	}
 }
+
+func TestCachedOrdersAreIsolatedFromCallers(t *testing.T) {
+	for name, storage := range map[string]protocols.OrderStorageInterface{
+		"local": NewLocalOrderStorage(),
+		"lru":   NewLRUOrderStorage(10, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			order := &domain.Order{OrderUID: "uid", Items: []domain.Item{{Name: "Mascaras"}}}
+			storage.Save(order.OrderUID, order)
+			order.Items[0].Name = "changed by producer"
+
+			first, err := storage.Get("uid")
+			assert.NoError(t, err)
+			first.Items[0].Name = "changed by reader"
+			first.Locale = "changed"
+
+			second, err := storage.Get("uid")
+			assert.NoError(t, err)
+			assert.Equal(t, "Mascaras", second.Items[0].Name)
+			assert.Equal(t, "", second.Locale)
+		})
+	}
+}
+
+func TestRepresentationsFollowOrderVersion(t *testing.T) {
+	for name, storage := range map[string]interface {
+		protocols.OrderStorageInterface
+		protocols.RepresentationStorageInterface
+		protocols.CacheAdminInterface
+	}{
+		"local": NewLocalOrderStorage(),
+		"lru":   NewLRUOrderStorage(10, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, generation, ok := storage.GetRepresentation("uid", "full")
+			assert.False(t, ok)
+			assert.Zero(t, generation)
+
+			storage.Save("uid", &domain.Order{OrderUID: "uid"})
+			_, generation, _ = storage.GetRepresentation("uid", "full")
+			storage.SaveRepresentation("uid", "full", generation, &protocols.Representation{ETag: `"v1"`})
+			representation, _, ok := storage.GetRepresentation("uid", "full")
+			assert.True(t, ok)
+			assert.Equal(t, `"v1"`, representation.ETag)
+			assert.Equal(t, uint64(1), storage.Stats().Hits, "only the found representation is a hit")
+
+			// Новая версия заказа сбрасывает представления, а запоздавшая запись по старому поколению отбрасывается
+			storage.Save("uid", &domain.Order{OrderUID: "uid", Locale: "en"})
+			storage.SaveRepresentation("uid", "full", generation, &protocols.Representation{ETag: `"stale"`})
+			_, _, ok = storage.GetRepresentation("uid", "full")
+			assert.False(t, ok)
+
+			storage.Delete("uid")
+			_, generation, ok = storage.GetRepresentation("uid", "full")
+			assert.False(t, ok)
+			assert.Zero(t, generation)
+		})
+	}
+}
+
+func TestAdminKeysAreSortedAndPaginated(t *testing.T) {
+	for name, storage := range map[string]interface {
+		protocols.OrderStorageInterface
+		protocols.CacheAdminInterface
+	}{
+		"local": NewLocalOrderStorage(),
+		"lru":   NewLRUOrderStorage(10, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, uid := range []string{"c", "a", "b"} {
+				storage.Save(uid, &domain.Order{OrderUID: uid})
+			}
+			assert.Equal(t, []string{"a", "b"}, storage.Keys("", 2))
+			assert.Equal(t, []string{"c"}, storage.Keys("b", 2))
+
+			_, _ = storage.Get("a")
+			_, _ = storage.Get("missing")
+			stats := storage.Stats()
+			assert.Equal(t, 3, stats.Entries)
+			assert.Equal(t, uint64(1), stats.Hits)
+			assert.Equal(t, uint64(1), stats.Misses)
+
+			storage.Flush()
+			assert.Zero(t, storage.Stats().Entries)
+			assert.Empty(t, storage.Keys("", 10))
+		})
+	}
+}
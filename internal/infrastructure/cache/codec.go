@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"web_service/internal/domain"
+)
+
+// OrderCodec сериализует заказы для хранения во внешнем кеше
+type OrderCodec interface {
+	Marshal(order *domain.Order) ([]byte, error)
+	Unmarshal(data []byte) (*domain.Order, error)
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(order *domain.Order) ([]byte, error) {
+	return json.Marshal(order)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (*domain.Order, error) {
+	var order domain.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GobCodec компактнее JSON и, в отличие от него, сохраняет поля с тегом json:"-"
+type GobCodec struct{}
+
+func (GobCodec) Marshal(order *domain.Order) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(order); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (*domain.Order, error) {
+	var order domain.Order
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func CodecByName(name string) (OrderCodec, error) {
+	switch name {
+	case "json":
+		return JSONCodec{}, nil
+	case "gob":
+		return GobCodec{}, nil
+	}
+	return nil, fmt.Errorf("unknown cache codec %q, expected json or gob", name)
+}
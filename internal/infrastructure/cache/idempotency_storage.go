@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"sync"
+	"time"
+	"web_service/internal/protocols"
+)
+
+type idempotencyEntry struct {
+	record    *protocols.IdempotencyRecord
+	expiresAt time.Time
+}
+
+// LocalIdempotencyStorage хранит ответы по ключам идемпотентности в памяти процесса, поэтому повтор,
+// пришедший на другую реплику, не распознаётся; для нескольких реплик есть RedisIdempotencyStorage.
+// Просроченная запись не отдаётся при резервировании, а из памяти удаляется общим проходом не чаще раза в ttl.
+type LocalIdempotencyStorage struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLocalIdempotencyStorage(ttl time.Duration) *LocalIdempotencyStorage {
+	return &LocalIdempotencyStorage{
+		ttl:     ttl,
+		entries: make(map[string]idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *LocalIdempotencyStorage) Reserve(key, fingerprint string) (*protocols.IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evictExpired(now)
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expiresAt) {
+		return entry.record, false
+	}
+	// Резерв живёт столько же, сколько ответ: зависший запрос не блокирует ключ навсегда
+	s.entries[key] = idempotencyEntry{
+		record:    &protocols.IdempotencyRecord{Fingerprint: fingerprint},
+		expiresAt: now.Add(s.ttl),
+	}
+	return nil, true
+}
+
+func (s *LocalIdempotencyStorage) Save(key string, record *protocols.IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = idempotencyEntry{record: record, expiresAt: s.now().Add(s.ttl)}
+}
+
+func (s *LocalIdempotencyStorage) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok && entry.record.InFlight() {
+		delete(s.entries, key)
+	}
+}
+
+// evictExpired удаляет просроченные записи не чаще раза в ttl, чтобы резервирование оставалось O(1) в среднем
+func (s *LocalIdempotencyStorage) evictExpired(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for k, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
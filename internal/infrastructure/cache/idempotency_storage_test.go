@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web_service/internal/protocols"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyReserveIsExclusive(t *testing.T) {
+	s := NewLocalIdempotencyStorage(time.Hour)
+
+	var winners atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, reserved := s.Reserve("key", "fp"); reserved {
+				winners.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), winners.Load())
+
+	record, reserved := s.Reserve("key", "fp")
+	require.False(t, reserved)
+	assert.True(t, record.InFlight())
+	assert.Equal(t, "fp", record.Fingerprint)
+}
+
+func TestIdempotencyReleaseKeepsSavedResponse(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewLocalIdempotencyStorage(time.Hour)
+	s.now = func() time.Time { return now }
+
+	_, reserved := s.Reserve("failed", "fp")
+	require.True(t, reserved)
+	s.Release("failed")
+	_, reserved = s.Reserve("failed", "fp")
+	assert.True(t, reserved, "released key can be retried")
+
+	s.Reserve("done", "fp")
+	s.Save("done", &protocols.IdempotencyRecord{Fingerprint: "fp", StatusCode: http.StatusCreated})
+	s.Release("done")
+	record, reserved := s.Reserve("done", "fp")
+	require.False(t, reserved)
+	assert.Equal(t, http.StatusCreated, record.StatusCode)
+
+	now = now.Add(2 * time.Hour)
+	_, reserved = s.Reserve("done", "fp")
+	assert.True(t, reserved, "expired response frees the key")
+	assert.Len(t, s.entries, 1, "expired entries are swept")
+}
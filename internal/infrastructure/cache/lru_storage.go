@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+type lruEntry struct {
+	orderUID        string
+	order           *domain.Order
+	savedAt         time.Time
+	expiresAt       time.Time
+	generation      uint64
+	representations map[string]*protocols.Representation
+}
+
+// LRUOrderStorage — ограниченный по размеру кеш в памяти процесса: при переполнении
+// вытесняется заказ, к которому дольше всего не обращались. ttl = 0 отключает устаревание.
+// Как и LocalOrderStorage, хранит и отдаёт копии заказов.
+type LRUOrderStorage struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+	// generations нумерует сохранения, чтобы представления старой версии заказа не попали в новую запись
+	generations uint64
+	hits        uint64
+	misses      uint64
+}
+
+func NewLRUOrderStorage(capacity int, ttl time.Duration) *LRUOrderStorage {
+	return &LRUOrderStorage{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+		now:      time.Now,
+	}
+}
+
+func (s *LRUOrderStorage) Get(orderUID string) (*domain.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(orderUID)
+	if !ok {
+		s.misses++
+		return nil, domain.OrderNotFoundError
+	}
+	s.hits++
+	return entry.order.Clone(), nil
+}
+
+func (s *LRUOrderStorage) Save(orderUID string, order *domain.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(orderUID, order)
+}
+
+func (s *LRUOrderStorage) SaveAll(orders []*domain.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, order := range orders {
+		s.store(order.OrderUID, order)
+	}
+}
+
+func (s *LRUOrderStorage) Delete(orderUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[orderUID]; ok {
+		s.remove(element)
+	}
+}
+
+func (s *LRUOrderStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Range обходит снимок содержимого, чтобы fn не выполнялся под блокировкой; fn не должен изменять заказы
+func (s *LRUOrderStorage) Range(fn func(order *domain.Order) bool) {
+	s.mu.Lock()
+	orders := make([]*domain.Order, 0, s.order.Len())
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		orders = append(orders, element.Value.(*lruEntry).order)
+	}
+	s.mu.Unlock()
+	for _, order := range orders {
+		if !fn(order) {
+			return
+		}
+	}
+}
+
+func (s *LRUOrderStorage) GetRepresentation(orderUID, variant string) (*protocols.Representation, uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(orderUID)
+	if !ok {
+		return nil, 0, false
+	}
+	representation, ok := entry.representations[variant]
+	// Промах по представлению не считаем: вызывающий после него читает заказ через Get
+	if ok {
+		s.hits++
+	}
+	return representation, entry.generation, ok
+}
+
+func (s *LRUOrderStorage) SaveRepresentation(orderUID, variant string, generation uint64,
+	representation *protocols.Representation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[orderUID]
+	if !ok {
+		return
+	}
+	entry := element.Value.(*lruEntry)
+	if entry.generation != generation {
+		return
+	}
+	if entry.representations == nil {
+		entry.representations = make(map[string]*protocols.Representation)
+	}
+	entry.representations[variant] = representation
+}
+
+func (s *LRUOrderStorage) Stats() protocols.CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := protocols.CacheStats{Entries: s.order.Len(), Hits: s.hits, Misses: s.misses}
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*lruEntry)
+		stats.Bytes += approxOrderSize(entry.order)
+		for _, representation := range entry.representations {
+			stats.Bytes += int64(len(representation.Body))
+		}
+		if stats.OldestEntry.IsZero() || entry.savedAt.Before(stats.OldestEntry) {
+			stats.OldestEntry = entry.savedAt
+		}
+	}
+	return stats
+}
+
+func (s *LRUOrderStorage) Keys(after string, limit int) []string {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+	return pageKeys(keys, after, limit)
+}
+
+func (s *LRUOrderStorage) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.order.Init()
+	clear(s.entries)
+}
+
+// lookup находит живую запись и отмечает обращение к ней; вызывается под s.mu
+func (s *LRUOrderStorage) lookup(orderUID string) (*lruEntry, bool) {
+	element, ok := s.entries[orderUID]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if s.ttl > 0 && s.now().After(entry.expiresAt) {
+		s.remove(element)
+		return nil, false
+	}
+	s.order.MoveToFront(element)
+	return entry, true
+}
+
+func (s *LRUOrderStorage) store(orderUID string, order *domain.Order) {
+	if s.capacity <= 0 {
+		return
+	}
+	order = order.Clone()
+	now := s.now()
+	expiresAt := now.Add(s.ttl)
+	s.generations++
+	if element, ok := s.entries[orderUID]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.order = order
+		entry.savedAt = now
+		entry.expiresAt = expiresAt
+		entry.generation = s.generations
+		entry.representations = nil
+		s.order.MoveToFront(element)
+		return
+	}
+	s.entries[orderUID] = s.order.PushFront(&lruEntry{orderUID: orderUID, order: order, savedAt: now, expiresAt: expiresAt,
+		generation: s.generations})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *LRUOrderStorage) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*lruEntry).orderUID)
+}
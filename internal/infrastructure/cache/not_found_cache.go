@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+	"web_service/internal/protocols"
+)
+
+type notFoundEntry struct {
+	missingUntil time.Time
+	forgottenAt  time.Time
+}
+
+// LocalNotFoundCache хранит короткоживущие отметки об отсутствующих заказах.
+// Момент последнего сохранения заказа тоже запоминается на ttl, чтобы запрос,
+// начатый до сохранения, не вернул устаревшую отметку обратно.
+type LocalNotFoundCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*notFoundEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLocalNotFoundCache(ttl time.Duration) *LocalNotFoundCache {
+	return &LocalNotFoundCache{ttl: ttl, entries: make(map[string]*notFoundEntry), now: time.Now}
+}
+
+func (c *LocalNotFoundCache) IsMissing(orderUID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[orderUID]
+	return ok && c.now().Before(entry.missingUntil)
+}
+
+func (c *LocalNotFoundCache) MarkMissing(orderUID string, observedAt time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.evictExpired(now)
+	entry, ok := c.entries[orderUID]
+	if !ok {
+		entry = &notFoundEntry{}
+		c.entries[orderUID] = entry
+	}
+	if !entry.forgottenAt.IsZero() && !entry.forgottenAt.Before(observedAt) {
+		return
+	}
+	entry.missingUntil = now.Add(c.ttl)
+}
+
+func (c *LocalNotFoundCache) Forget(orderUID string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.evictExpired(now)
+	c.entries[orderUID] = &notFoundEntry{forgottenAt: now}
+}
+
+// evictExpired удаляет устаревшие записи не чаще раза в ttl
+func (c *LocalNotFoundCache) evictExpired(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for uid, entry := range c.entries {
+		if now.After(entry.missingUntil) && now.Sub(entry.forgottenAt) > c.ttl {
+			delete(c.entries, uid)
+		}
+	}
+}
+
+// SharedNotFoundCache — LocalNotFoundCache, который рассылает снятие отметок другим репликам. Без этого реплика,
+// не сохранявшая заказ, отвечала бы 404 на уже созданный заказ до истечения ttl. Pub/sub не гарантирует
+// доставку, поэтому при потере сообщения отметка всё равно живёт не дольше ttl.
+type SharedNotFoundCache struct {
+	*LocalNotFoundCache
+	bus protocols.CacheInvalidationBusInterface
+}
+
+func NewSharedNotFoundCache(local *LocalNotFoundCache, bus protocols.CacheInvalidationBusInterface) *SharedNotFoundCache {
+	return &SharedNotFoundCache{LocalNotFoundCache: local, bus: bus}
+}
+
+// Listen снимает отметки по сообщениям других реплик до отмены ctx
+func (c *SharedNotFoundCache) Listen(ctx context.Context) error {
+	return c.bus.Subscribe(ctx, c.LocalNotFoundCache.Forget)
+}
+
+func (c *SharedNotFoundCache) Forget(orderUID string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.LocalNotFoundCache.Forget(orderUID)
+	c.bus.Publish(orderUID)
+}
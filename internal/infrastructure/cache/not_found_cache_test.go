@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotFoundCacheExpiresAndForgets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLocalNotFoundCache(5 * time.Second)
+	c.now = func() time.Time { return now }
+
+	c.MarkMissing("uid", now)
+	assert.True(t, c.IsMissing("uid"))
+
+	c.Forget("uid")
+	assert.False(t, c.IsMissing("uid"))
+
+	c.MarkMissing("other", now)
+	now = now.Add(6 * time.Second)
+	assert.False(t, c.IsMissing("other"))
+}
+
+func TestNotFoundCacheIgnoresMissObservedBeforeSave(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLocalNotFoundCache(5 * time.Second)
+	c.now = func() time.Time { return now }
+
+	lookupStarted := now
+	now = now.Add(time.Millisecond)
+	c.Forget("uid")
+	c.MarkMissing("uid", lookupStarted)
+
+	assert.False(t, c.IsMissing("uid"))
+}
+
+func TestSharedNotFoundCacheForgetsOnOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	newReplica := func() *SharedNotFoundCache {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		c := NewSharedNotFoundCache(NewLocalNotFoundCache(time.Minute),
+			NewRedisInvalidationBus(client, "orders:invalidate:not-found"))
+		require.NoError(t, c.Listen(ctx))
+		return c
+	}
+	reader, writer := newReplica(), newReplica()
+
+	reader.MarkMissing("uid", time.Now())
+	require.True(t, reader.IsMissing("uid"))
+
+	writer.Forget("uid")
+	assert.Eventually(t, func() bool { return !reader.IsMissing("uid") }, time.Second, 10*time.Millisecond)
+}
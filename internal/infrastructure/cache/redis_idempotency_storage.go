@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"web_service/internal/protocols"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIdempotencyStorage хранит ответы по ключам идемпотентности в Redis, общем для всех реплик,
+// поэтому повтор запроса распознаётся, на какую бы реплику он ни пришёл
+type RedisIdempotencyStorage struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	ttl       time.Duration
+}
+
+func NewRedisIdempotencyStorage(client redis.UniversalClient, keyPrefix string,
+	ttl time.Duration) *RedisIdempotencyStorage {
+	return &RedisIdempotencyStorage{client: client, keyPrefix: keyPrefix, ttl: ttl}
+}
+
+// Reserve занимает ключ через SET NX. Если Redis недоступен, запрос выполняется без защиты от повторов:
+// отказывать в создании заказа из-за кеша хуже, чем изредка пропустить дубликат.
+func (s *RedisIdempotencyStorage) Reserve(key, fingerprint string) (*protocols.IdempotencyRecord, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	marker, err := json.Marshal(&protocols.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		log.Printf("could not encode idempotency marker for key %s: %v\n", key, err)
+		return nil, true
+	}
+	// Запись может истечь между SET NX и GET, тогда пробуем занять ключ ещё раз
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.client.SetNX(ctx, s.keyPrefix+key, marker, s.ttl).Result()
+		if err != nil {
+			log.Printf("redis reserve idempotency key %s error: %v\n", key, err)
+			return nil, true
+		}
+		if reserved {
+			return nil, true
+		}
+		record, err := s.get(ctx, s.client, key)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			log.Printf("redis get idempotency key %s error: %v\n", key, err)
+			return nil, true
+		}
+		return record, false
+	}
+	return nil, true
+}
+
+func (s *RedisIdempotencyStorage) Save(key string, record *protocols.IdempotencyRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("could not encode idempotency record for key %s: %v\n", key, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	if err := s.client.Set(ctx, s.keyPrefix+key, data, s.ttl).Err(); err != nil {
+		log.Printf("redis save idempotency key %s error: %v\n", key, err)
+	}
+}
+
+// Release удаляет только резерв: готовый ответ, сохранённый к этому моменту, остаётся
+func (s *RedisIdempotencyStorage) Release(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	redisKey := s.keyPrefix + key
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		record, err := s.get(ctx, tx, key)
+		if err != nil || !record.InFlight() {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, redisKey)
+			return nil
+		})
+		return err
+	}, redisKey)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("redis release idempotency key %s error: %v\n", key, err)
+	}
+}
+
+func (s *RedisIdempotencyStorage) get(ctx context.Context, client redis.Cmdable,
+	key string) (*protocols.IdempotencyRecord, error) {
+	data, err := client.Get(ctx, s.keyPrefix+key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var record protocols.IdempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"web_service/internal/protocols"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisIdempotencyIsSharedBetweenReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	newReplica := func() *RedisIdempotencyStorage {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		return NewRedisIdempotencyStorage(client, "orders:idempotency:", time.Hour)
+	}
+	first, second := newReplica(), newReplica()
+
+	_, reserved := first.Reserve("key", "fp")
+	require.True(t, reserved)
+	record, reserved := second.Reserve("key", "fp")
+	require.False(t, reserved)
+	assert.True(t, record.InFlight())
+
+	first.Save("key", &protocols.IdempotencyRecord{Fingerprint: "fp", StatusCode: http.StatusCreated, Body: []byte("{}")})
+	second.Release("key")
+	record, reserved = second.Reserve("key", "fp")
+	require.False(t, reserved)
+	assert.Equal(t, http.StatusCreated, record.StatusCode)
+	assert.Equal(t, []byte("{}"), record.Body)
+
+	_, reserved = first.Reserve("failed", "fp")
+	require.True(t, reserved)
+	first.Release("failed")
+	_, reserved = second.Reserve("failed", "fp")
+	assert.True(t, reserved, "released key can be retried on another replica")
+
+	server.FastForward(2 * time.Hour)
+	_, reserved = second.Reserve("key", "fp")
+	assert.True(t, reserved, "expired response frees the key")
+}
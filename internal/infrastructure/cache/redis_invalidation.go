@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisInvalidationBus передаёт инвалидации через Redis pub/sub.
+// Сообщение имеет вид "<instance id>|<order uid>", свои сообщения реплика пропускает.
+type RedisInvalidationBus struct {
+	client     redis.UniversalClient
+	channel    string
+	instanceID string
+}
+
+func NewRedisInvalidationBus(client redis.UniversalClient, channel string) *RedisInvalidationBus {
+	return &RedisInvalidationBus{client: client, channel: channel, instanceID: uuid.NewString()}
+}
+
+func (b *RedisInvalidationBus) Publish(orderUID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	if err := b.client.Publish(ctx, b.channel, b.instanceID+"|"+orderUID).Err(); err != nil {
+		log.Printf("redis publish invalidation for order %s error: %v\n", orderUID, err)
+	}
+}
+
+func (b *RedisInvalidationBus) Subscribe(ctx context.Context, handler func(orderUID string)) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// Дожидаемся подтверждения подписки, чтобы не потерять инвалидации сразу после старта
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	go func() {
+		defer func() { _ = pubsub.Close() }()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				sender, orderUID, found := strings.Cut(msg.Payload, "|")
+				if !found || sender == b.instanceID {
+					continue
+				}
+				handler(orderUID)
+			}
+		}
+	}()
+	return nil
+}
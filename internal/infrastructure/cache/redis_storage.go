@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Таймаут одной операции с Redis: интерфейс хранилища не принимает контекст
+const redisOperationTimeout = time.Second
+
+// RedisOrderStorage — общий для всех реплик кеш заказов в Redis
+type RedisOrderStorage struct {
+	client    redis.UniversalClient
+	codec     OrderCodec
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewRedisOrderStorage создаёт хранилище поверх готового клиента; ttl = 0 хранит заказы бессрочно
+func NewRedisOrderStorage(client redis.UniversalClient, codec OrderCodec, keyPrefix string,
+	ttl time.Duration) *RedisOrderStorage {
+	return &RedisOrderStorage{client: client, codec: codec, keyPrefix: keyPrefix, ttl: ttl}
+}
+
+func (s *RedisOrderStorage) key(orderUID string) string {
+	return s.keyPrefix + orderUID
+}
+
+func (s *RedisOrderStorage) Get(orderUID string) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	data, err := s.client.Get(ctx, s.key(orderUID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		log.Printf("order %s not found in redis cache\n", orderUID)
+		return nil, domain.OrderNotFoundError
+	}
+	if err != nil {
+		log.Printf("redis get order %s error: %v\n", orderUID, err)
+		return nil, fmt.Errorf("%w: redis error: %v", domain.OrderNotFoundError, err)
+	}
+	order, err := s.codec.Unmarshal(data)
+	if err != nil {
+		log.Printf("order %s in redis cache could not be decoded: %v\n", orderUID, err)
+		return nil, domain.OrderNotFoundError
+	}
+	return order, nil
+}
+
+func (s *RedisOrderStorage) Save(orderUID string, order *domain.Order) {
+	data, err := s.codec.Marshal(order)
+	if err != nil {
+		log.Printf("could not encode order %s for redis cache: %v\n", orderUID, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	if err := s.client.Set(ctx, s.key(orderUID), data, s.ttl).Err(); err != nil {
+		log.Printf("redis save order %s error: %v\n", orderUID, err)
+	}
+}
+
+// SaveAll записывает заказы одним pipeline, чтобы прогрев не тратил round-trip на каждый заказ
+func (s *RedisOrderStorage) SaveAll(orders []*domain.Order) {
+	if len(orders) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout+time.Duration(len(orders))*time.Millisecond)
+	defer cancel()
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, order := range orders {
+			data, err := s.codec.Marshal(order)
+			if err != nil {
+				log.Printf("could not encode order %s for redis cache: %v\n", order.OrderUID, err)
+				continue
+			}
+			pipe.Set(ctx, s.key(order.OrderUID), data, s.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis bulk save of %d orders error: %v\n", len(orders), err)
+	}
+}
+
+func (s *RedisOrderStorage) Delete(orderUID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	if err := s.client.Del(ctx, s.key(orderUID)).Err(); err != nil {
+		log.Printf("redis delete order %s error: %v\n", orderUID, err)
+	}
+}
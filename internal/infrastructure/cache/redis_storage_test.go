@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisStorage(t *testing.T, codec OrderCodec) (*RedisOrderStorage, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisOrderStorage(client, codec, "orders:", time.Minute), server
+}
+
+func TestRedisStorageRoundTrip(t *testing.T) {
+	for name, codec := range map[string]OrderCodec{"json": JSONCodec{}, "gob": GobCodec{}} {
+		t.Run(name, func(t *testing.T) {
+			var storage protocols.OrderStorageInterface
+			storage, server := newTestRedisStorage(t, codec)
+			order := &domain.Order{
+				OrderUID: "uid",
+				Payment:  domain.Payment{Amount: 1817},
+				Items:    []domain.Item{{Rid: "rid", Name: "Mascaras"}},
+			}
+
+			storage.Save(order.OrderUID, order)
+			actual, err := storage.Get("uid")
+
+			require.NoError(t, err)
+			assert.Equal(t, order.Payment.Amount, actual.Payment.Amount)
+			assert.Equal(t, order.Items, actual.Items)
+			assert.True(t, server.Exists("orders:uid"))
+			assert.Equal(t, time.Minute, server.TTL("orders:uid"))
+
+			storage.Delete("uid")
+			_, err = storage.Get("uid")
+			assert.True(t, errors.Is(err, domain.OrderNotFoundError))
+		})
+	}
+}
+
+func TestRedisStorageSaveAllUsesSingleKeyspace(t *testing.T) {
+	storage, server := newTestRedisStorage(t, JSONCodec{})
+	orders := make([]*domain.Order, 0, 100)
+	for i := 0; i < 100; i++ {
+		orders = append(orders, &domain.Order{OrderUID: strconv.Itoa(i)})
+	}
+
+	storage.SaveAll(orders)
+
+	assert.Len(t, server.Keys(), 100)
+	actual, err := storage.Get("42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", actual.OrderUID)
+}
+
+func TestRedisStorageTreatsUnavailableServerAsMiss(t *testing.T) {
+	storage, server := newTestRedisStorage(t, JSONCodec{})
+	server.Close()
+
+	_, err := storage.Get("uid")
+
+	assert.True(t, errors.Is(err, domain.OrderNotFoundError))
+}
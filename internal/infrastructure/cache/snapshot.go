@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+// Формат файла снимка:
+//
+//	magic "WBOS" | version uint16 | watermark int64 (unix nano) | count uint32 | gzip(gob([]domain.Order)) | sha256
+//
+// Контрольная сумма покрывает всё, что перед ней. Версию нужно поднимать при любом
+// несовместимом изменении domain.Order.
+const (
+	snapshotMagic   = "WBOS"
+	snapshotVersion = uint16(4)
+	snapshotHeader  = len(snapshotMagic) + 2 + 8 + 4
+)
+
+var SnapshotCorruptedError = errors.New("cache snapshot is corrupted")
+
+// Snapshot — содержимое кеша на момент записи. Watermark — максимальная updated_at среди заказов.
+type Snapshot struct {
+	Watermark time.Time
+	Orders    []*domain.Order
+}
+
+// Snapshotter периодически сохраняет содержимое кеша в файл и восстанавливает его при старте
+type Snapshotter struct {
+	storage protocols.IterableOrderStorageInterface
+	path    string
+}
+
+func NewSnapshotter(storage protocols.IterableOrderStorageInterface, path string) *Snapshotter {
+	return &Snapshotter{storage: storage, path: path}
+}
+
+// Run записывает снимок каждые interval и ещё раз при отмене ctx
+func (s *Snapshotter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Write(); err != nil {
+				log.Printf("Failed to write final cache snapshot: %v\n", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Write(); err != nil {
+				log.Printf("Failed to write cache snapshot: %v\n", err)
+			}
+		}
+	}
+}
+
+// Write атомарно заменяет файл снимка: данные пишутся во временный файл в том же каталоге и переименовываются
+func (s *Snapshotter) Write() error {
+	var orders []domain.Order
+	var watermark time.Time
+	s.storage.Range(func(order *domain.Order) bool {
+		orders = append(orders, *order)
+		if order.UpdatedAt.After(watermark) {
+			watermark = order.UpdatedAt
+		}
+		return true
+	})
+
+	data, err := encodeSnapshot(watermark, orders)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	log.Printf("Cache snapshot with %d orders written to %s\n", len(orders), s.path)
+	return nil
+}
+
+// Load читает снимок. Повреждённый файл или файл другой версии возвращает SnapshotCorruptedError.
+func (s *Snapshotter) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func encodeSnapshot(watermark time.Time, orders []domain.Order) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	_ = binary.Write(&buf, binary.BigEndian, snapshotVersion)
+	var watermarkNano int64
+	if !watermark.IsZero() {
+		watermarkNano = watermark.UnixNano()
+	}
+	_ = binary.Write(&buf, binary.BigEndian, watermarkNano)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(orders)))
+
+	zw := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(zw).Encode(orders); err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress snapshot: %w", err)
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	buf.Write(sum[:])
+	return buf.Bytes(), nil
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	if len(data) < snapshotHeader+sha256.Size || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("%w: unknown format", SnapshotCorruptedError)
+	}
+	body, checksum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", SnapshotCorruptedError)
+	}
+
+	header := body[len(snapshotMagic):snapshotHeader]
+	if version := binary.BigEndian.Uint16(header[0:2]); version != snapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", SnapshotCorruptedError, version, snapshotVersion)
+	}
+	var watermark time.Time
+	if nano := int64(binary.BigEndian.Uint64(header[2:10])); nano != 0 {
+		watermark = time.Unix(0, nano)
+	}
+	count := binary.BigEndian.Uint32(header[10:14])
+
+	zr, err := gzip.NewReader(bytes.NewReader(body[snapshotHeader:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", SnapshotCorruptedError, err)
+	}
+	var orders []domain.Order
+	if err := gob.NewDecoder(zr).Decode(&orders); err != nil {
+		return nil, fmt.Errorf("%w: %v", SnapshotCorruptedError, err)
+	}
+	if uint32(len(orders)) != count {
+		return nil, fmt.Errorf("%w: expected %d orders, got %d", SnapshotCorruptedError, count, len(orders))
+	}
+
+	snapshot := &Snapshot{Watermark: watermark, Orders: make([]*domain.Order, len(orders))}
+	for i := range orders {
+		snapshot.Orders[i] = &orders[i]
+	}
+	return snapshot, nil
+}
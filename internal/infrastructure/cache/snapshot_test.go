@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestSnapshot(t *testing.T) (string, time.Time) {
+	storage := NewLocalOrderStorage()
+	newest := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Отметка снимка считается по updated_at: старый заказ изменён позже, чем создан новый
+	storage.Save("old", &domain.Order{OrderUID: "old", DateCreated: newest.Add(-2 * time.Hour),
+		UpdatedAt: newest})
+	storage.Save("new", &domain.Order{OrderUID: "new", DateCreated: newest.Add(-time.Hour),
+		UpdatedAt: newest.Add(-time.Hour), Items: []domain.Item{{Rid: "rid", Name: "Mascaras"}}})
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	require.NoError(t, NewSnapshotter(storage, path).Write())
+	return path, newest
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path, newest := writeTestSnapshot(t)
+
+	snapshot, err := NewSnapshotter(NewLocalOrderStorage(), path).Load()
+
+	require.NoError(t, err)
+	assert.True(t, newest.Equal(snapshot.Watermark))
+	assert.Len(t, snapshot.Orders, 2)
+	for _, order := range snapshot.Orders {
+		if order.OrderUID == "new" {
+			assert.Equal(t, "Mascaras", order.Items[0].Name)
+		}
+	}
+}
+
+func TestSnapshotRejectsCorruptedAndForeignVersions(t *testing.T) {
+	path, _ := writeTestSnapshot(t)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	corrupted := append([]byte(nil), original...)
+	corrupted[len(corrupted)/2] ^= 0xFF
+
+	otherVersion := append([]byte(nil), original...)
+	binary.BigEndian.PutUint16(otherVersion[len(snapshotMagic):], snapshotVersion+1)
+
+	for name, data := range map[string][]byte{
+		"corrupted":     corrupted,
+		"other version": otherVersion,
+		"truncated":     original[:10],
+	} {
+		_, err := decodeSnapshot(data)
+		assert.True(t, errors.Is(err, SnapshotCorruptedError), name)
+	}
+}
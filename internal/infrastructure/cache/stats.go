@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"slices"
+	"unsafe"
+	"web_service/internal/domain"
+)
+
+// approxOrderSize оценивает объём заказа в памяти: размер структур плюс содержимое строк
+func approxOrderSize(order *domain.Order) int64 {
+	size := int64(unsafe.Sizeof(*order)) + int64(len(order.Items))*int64(unsafe.Sizeof(domain.Item{}))
+	for _, s := range []string{order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+		order.InternalSignature, order.CustomerID, order.DeliveryService, order.Shardkey, order.OofShard,
+		order.Delivery.ID, order.Delivery.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
+		order.Payment.ID, order.Payment.Transaction, order.Payment.RequestID, string(order.Payment.Currency),
+		order.Payment.Provider, order.Payment.Bank} {
+		size += int64(len(s))
+	}
+	for i := range order.Items {
+		item := &order.Items[i]
+		size += int64(len(item.Rid) + len(item.TrackNumber) + len(item.Name) + len(item.Size) + len(item.Brand))
+	}
+	return size
+}
+
+// pageKeys сортирует ключи и возвращает до limit ключей, следующих после after
+func pageKeys(keys []string, after string, limit int) []string {
+	slices.Sort(keys)
+	start, _ := slices.BinarySearch(keys, after)
+	if start < len(keys) && keys[start] == after {
+		start++
+	}
+	end := min(start+limit, len(keys))
+	if start >= end {
+		return []string{}
+	}
+	return keys[start:end]
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+// TieredOrderStorage — двухуровневый кеш: небольшой локальный L1 перед общим L2.
+// Промах L1 читается из L2 и продвигается в L1; изменения рассылаются другим репликам,
+// чтобы те вытеснили устаревшую копию из своего L1.
+type TieredOrderStorage struct {
+	l1  protocols.OrderStorageInterface
+	l2  protocols.OrderStorageInterface
+	bus protocols.CacheInvalidationBusInterface
+}
+
+func NewTieredOrderStorage(l1, l2 protocols.OrderStorageInterface,
+	bus protocols.CacheInvalidationBusInterface) *TieredOrderStorage {
+	return &TieredOrderStorage{l1: l1, l2: l2, bus: bus}
+}
+
+// Listen подписывается на инвалидации от других реплик до отмены ctx
+func (s *TieredOrderStorage) Listen(ctx context.Context) error {
+	return s.bus.Subscribe(ctx, s.l1.Delete)
+}
+
+func (s *TieredOrderStorage) Get(orderUID string) (*domain.Order, error) {
+	if order, err := s.l1.Get(orderUID); err == nil {
+		return order, nil
+	}
+	order, err := s.l2.Get(orderUID)
+	if err != nil {
+		return nil, err
+	}
+	s.l1.Save(orderUID, order)
+	return order, nil
+}
+
+func (s *TieredOrderStorage) Save(orderUID string, order *domain.Order) {
+	s.l2.Save(orderUID, order)
+	s.l1.Save(orderUID, order)
+	s.bus.Publish(orderUID)
+}
+
+// SaveAll используется для прогрева и пишет только в L2: L1 заполняется горячими заказами по мере чтения
+func (s *TieredOrderStorage) SaveAll(orders []*domain.Order) {
+	s.l2.SaveAll(orders)
+}
+
+func (s *TieredOrderStorage) Delete(orderUID string) {
+	s.l2.Delete(orderUID)
+	s.l1.Delete(orderUID)
+	s.bus.Publish(orderUID)
+}
+
+// GetRepresentation и SaveRepresentation работают только с L1: представления не покидают реплику
+func (s *TieredOrderStorage) GetRepresentation(orderUID, variant string) (*protocols.Representation, uint64, bool) {
+	if representations, ok := s.l1.(protocols.RepresentationStorageInterface); ok {
+		return representations.GetRepresentation(orderUID, variant)
+	}
+	return nil, 0, false
+}
+
+func (s *TieredOrderStorage) SaveRepresentation(orderUID, variant string, generation uint64,
+	representation *protocols.Representation) {
+	if representations, ok := s.l1.(protocols.RepresentationStorageInterface); ok {
+		representations.SaveRepresentation(orderUID, variant, generation, representation)
+	}
+}
+
+// Stats, Keys и Flush относятся к L1 этой реплики: общий L2 администрируется средствами Redis
+func (s *TieredOrderStorage) Stats() protocols.CacheStats {
+	if admin, ok := s.l1.(protocols.CacheAdminInterface); ok {
+		return admin.Stats()
+	}
+	return protocols.CacheStats{}
+}
+
+func (s *TieredOrderStorage) Keys(after string, limit int) []string {
+	if admin, ok := s.l1.(protocols.CacheAdminInterface); ok {
+		return admin.Keys(after, limit)
+	}
+	return []string{}
+}
+
+func (s *TieredOrderStorage) Flush() {
+	if admin, ok := s.l1.(protocols.CacheAdminInterface); ok {
+		admin.Flush()
+	}
+}
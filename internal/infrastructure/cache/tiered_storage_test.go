@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUStorageEvictsLeastRecentlyUsed(t *testing.T) {
+	storage := NewLRUOrderStorage(2, 0)
+	storage.Save("a", &domain.Order{OrderUID: "a"})
+	storage.Save("b", &domain.Order{OrderUID: "b"})
+	_, _ = storage.Get("a")
+	storage.Save("c", &domain.Order{OrderUID: "c"})
+
+	_, err := storage.Get("b")
+	assert.True(t, errors.Is(err, domain.OrderNotFoundError))
+	_, err = storage.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, storage.Len())
+}
+
+func newTestReplica(t *testing.T, ctx context.Context, server *miniredis.Miniredis) (*TieredOrderStorage, *LRUOrderStorage) {
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	l1 := NewLRUOrderStorage(10, time.Minute)
+	tiered := NewTieredOrderStorage(l1, NewRedisOrderStorage(client, JSONCodec{}, "orders:", 0),
+		NewRedisInvalidationBus(client, "orders:invalidate"))
+	require.NoError(t, tiered.Listen(ctx))
+	return tiered, l1
+}
+
+func TestTieredStoragePromotesAndInvalidatesAcrossReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	first, firstL1 := newTestReplica(t, ctx, server)
+	second, _ := newTestReplica(t, ctx, server)
+
+	second.Save("uid", &domain.Order{OrderUID: "uid", Locale: "en"})
+	order, err := first.Get("uid")
+	require.NoError(t, err)
+	assert.Equal(t, "en", order.Locale)
+	assert.Equal(t, 1, firstL1.Len(), "L2 hit must be promoted to L1")
+
+	second.Save("uid", &domain.Order{OrderUID: "uid", Locale: "ru"})
+
+	assert.Eventually(t, func() bool { return firstL1.Len() == 0 }, time.Second, 10*time.Millisecond)
+	order, err = first.Get("uid")
+	require.NoError(t, err)
+	assert.Equal(t, "ru", order.Locale)
+}
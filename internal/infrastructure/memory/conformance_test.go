@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"testing"
+	"web_service/internal/infrastructure/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Backend {
+		store := NewStore()
+		return repotest.Backend{
+			Orders:     NewOrderRepo(store),
+			Deliveries: NewDeliveryRepo(store),
+			Payments:   NewPaymentRepo(store),
+			Operations: NewPaymentOperationRepo(store),
+			Items:      NewItemRepo(store),
+			Statuses:   NewOrderStatusRepo(store),
+			Tx:         NewTransactionManager(store),
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"web_service/internal/domain"
+)
+
+type DeliveryRepo struct {
+	store *Store
+}
+
+func NewDeliveryRepo(store *Store) *DeliveryRepo {
+	return &DeliveryRepo{store: store}
+}
+
+func (r *DeliveryRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Delivery, error) {
+	var deliveries []domain.Delivery
+	r.store.read(ctx, func(data *state) {
+		deliveries = data.deliveries[orderUid]
+	})
+	if len(deliveries) == 0 {
+		return nil, fmt.Errorf("get delivery of order %s: %w", orderUid, domain.NotFoundError)
+	}
+	delivery := deliveries[0]
+	return &delivery, nil
+}
+
+func (r *DeliveryRepo) Save(ctx context.Context, delivery *domain.Delivery) error {
+	return r.store.write(ctx, func(data *state) error {
+		if _, ok := data.orders[delivery.OrderUID]; !ok {
+			return fmt.Errorf("failed to save delivery: %w: no order with uid %s", domain.ConflictError,
+				delivery.OrderUID)
+		}
+		data.deliveries[delivery.OrderUID] = append(data.deliveries[delivery.OrderUID], *delivery)
+		return nil
+	})
+}
+
+func (r *DeliveryRepo) Update(ctx context.Context, delivery *domain.Delivery) error {
+	return r.store.write(ctx, func(data *state) error {
+		deliveries := data.deliveries[delivery.OrderUID]
+		if len(deliveries) == 0 {
+			return fmt.Errorf("update delivery of order %s: %w", delivery.OrderUID, domain.NotFoundError)
+		}
+		for i := range deliveries {
+			row := *delivery
+			row.ID = deliveries[i].ID
+			deliveries[i] = row
+		}
+		return nil
+	})
+}
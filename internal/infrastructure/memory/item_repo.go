@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"web_service/internal/domain"
+)
+
+type ItemRepo struct {
+	store *Store
+}
+
+func NewItemRepo(store *Store) *ItemRepo {
+	return &ItemRepo{store: store}
+}
+
+func (r *ItemRepo) GetByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Item, error) {
+	var items []domain.Item
+	r.store.read(ctx, func(data *state) {
+		for _, rid := range data.itemsByTrack[trackNumber] {
+			items = append(items, data.items[rid])
+		}
+	})
+	return items, nil
+}
+
+func (r *ItemRepo) Save(ctx context.Context, item *domain.Item) error {
+	return r.store.write(ctx, func(data *state) error {
+		if err := insertItem(data, item); err != nil {
+			return fmt.Errorf("failed to save item: %w", err)
+		}
+		return nil
+	})
+}
+
+// SaveAll сохраняет товары атомарно: при ошибке не сохраняется ни один, а ошибка — *domain.ItemSaveError
+func (r *ItemRepo) SaveAll(ctx context.Context, items []domain.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.store.write(ctx, func(data *state) error {
+		for i := range items {
+			if err := insertItem(data, &items[i]); err != nil {
+				return fmt.Errorf("failed to save items: %w", &domain.ItemSaveError{Index: i, Rid: items[i].Rid, Err: err})
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ItemRepo) Delete(ctx context.Context, trackNumber, rid string) error {
+	return r.store.write(ctx, func(data *state) error {
+		rids := data.itemsByTrack[trackNumber]
+		i := slices.Index(rids, rid)
+		if i < 0 {
+			return fmt.Errorf("delete item %s: %w: no such item in order with track number %s",
+				rid, domain.NotFoundError, trackNumber)
+		}
+		data.itemsByTrack[trackNumber] = slices.Delete(rids, i, i+1)
+		delete(data.items, rid)
+		return nil
+	})
+}
+
+func insertItem(data *state, item *domain.Item) error {
+	if _, ok := data.items[item.Rid]; ok {
+		return fmt.Errorf("%w: duplicate item rid %s", domain.ConflictError, item.Rid)
+	}
+	if _, ok := data.tracks[item.TrackNumber]; !ok {
+		return fmt.Errorf("%w: no order with track number %s", domain.ConflictError, item.TrackNumber)
+	}
+	data.items[item.Rid] = *item
+	data.itemsByTrack[item.TrackNumber] = append(data.itemsByTrack[item.TrackNumber], item.Rid)
+	return nil
+}
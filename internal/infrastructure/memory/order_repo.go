@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"web_service/internal/domain"
+)
+
+type OrderRepo struct {
+	store *Store
+}
+
+func NewOrderRepo(store *Store) *OrderRepo {
+	return &OrderRepo{store: store}
+}
+
+func (r *OrderRepo) GetById(ctx context.Context, orderUid string) (*domain.Order, error) {
+	var order domain.Order
+	var ok bool
+	r.store.read(ctx, func(data *state) {
+		order, ok = data.orders[orderUid]
+	})
+	if !ok {
+		return nil, fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+	}
+	return &order, nil
+}
+
+func (r *OrderRepo) Save(ctx context.Context, order *domain.Order) error {
+	return r.store.write(ctx, func(data *state) error {
+		if _, ok := data.orders[order.OrderUID]; ok {
+			return fmt.Errorf("%w: database already has order with uid %s", domain.OrderAlreadyExistsError,
+				order.OrderUID)
+		}
+		if _, ok := data.tracks[order.TrackNumber]; ok {
+			return fmt.Errorf("save order: %w: duplicate track number %s", domain.ConflictError, order.TrackNumber)
+		}
+		// Как в SQL-бэкендах, строка заказа не хранит ни частей заказа, ни истории статусов:
+		// история живёт отдельно, а срез вызывающего не должен оказаться в хранилище
+		row := *order
+		row.Delivery, row.Payment, row.Items, row.StatusHistory = domain.Delivery{}, domain.Payment{}, nil, nil
+		data.orders[order.OrderUID] = row
+		data.tracks[order.TrackNumber] = order.OrderUID
+		return nil
+	})
+}
+
+func (r *OrderRepo) UpdateVersion(ctx context.Context, orderUid string, version int) error {
+	return r.store.write(ctx, func(data *state) error {
+		order, ok := data.orders[orderUid]
+		if !ok {
+			return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+		}
+		if order.Version != version {
+			return fmt.Errorf("%w: order %s changed concurrently, version %d instead of %d",
+				domain.RetryableError, orderUid, order.Version, version)
+		}
+		order.Version++
+		// Как PostgreSQL и SQLite, храним время с точностью до микросекунд
+		order.UpdatedAt = time.Now().Truncate(time.Microsecond)
+		data.orders[orderUid] = order
+		return nil
+	})
+}
+
+func (r *OrderRepo) GetAllOrderUIDs(ctx context.Context) ([]string, error) {
+	return r.orderUIDs(ctx, func(domain.Order) bool { return true }), nil
+}
+
+func (r *OrderRepo) GetOrderUIDsUpdatedAfter(ctx context.Context, since time.Time) ([]string, error) {
+	return r.orderUIDs(ctx, func(order domain.Order) bool { return order.UpdatedAt.After(since) }), nil
+}
+
+func (r *OrderRepo) orderUIDs(ctx context.Context, match func(order domain.Order) bool) []string {
+	result := make([]string, 0)
+	r.store.read(ctx, func(data *state) {
+		for uid, order := range data.orders {
+			if match(order) {
+				result = append(result, uid)
+			}
+		}
+	})
+	return result
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"web_service/internal/domain"
+)
+
+type OrderStatusRepo struct {
+	store *Store
+}
+
+func NewOrderStatusRepo(store *Store) *OrderStatusRepo {
+	return &OrderStatusRepo{store: store}
+}
+
+func (r *OrderStatusRepo) UpdateStatus(ctx context.Context, orderUid string, from, to domain.OrderStatus) error {
+	return r.store.write(ctx, func(data *state) error {
+		order, ok := data.orders[orderUid]
+		if !ok {
+			return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+		}
+		if order.Status != from {
+			return fmt.Errorf("%w: status of order %s changed concurrently from %s to %s",
+				domain.RetryableError, orderUid, from, order.Status)
+		}
+		order.Status = to
+		// Как PostgreSQL и SQLite, храним время с точностью до микросекунд
+		order.UpdatedAt = time.Now().Truncate(time.Microsecond)
+		data.orders[orderUid] = order
+		return nil
+	})
+}
+
+func (r *OrderStatusRepo) AddHistory(ctx context.Context, orderUid string, change domain.StatusChange) error {
+	return r.store.write(ctx, func(data *state) error {
+		if _, ok := data.orders[orderUid]; !ok {
+			return fmt.Errorf("add status history: %w: no order with uid %s", domain.ConflictError, orderUid)
+		}
+		data.history[orderUid] = append(data.history[orderUid], change)
+		return nil
+	})
+}
+
+func (r *OrderStatusRepo) GetHistory(ctx context.Context, orderUid string) ([]domain.StatusChange, error) {
+	var history []domain.StatusChange
+	r.store.read(ctx, func(data *state) {
+		history = slices.Clone(data.history[orderUid])
+	})
+	if history == nil {
+		history = make([]domain.StatusChange, 0)
+	}
+	// Как ORDER BY changed_at, id: при равном времени сохраняется порядок добавления
+	sort.SliceStable(history, func(i, j int) bool { return history[i].ChangedAt.Before(history[j].ChangedAt) })
+	return history, nil
+}
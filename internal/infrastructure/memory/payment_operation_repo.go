@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"web_service/internal/domain"
+)
+
+type PaymentOperationRepo struct {
+	store *Store
+}
+
+func NewPaymentOperationRepo(store *Store) *PaymentOperationRepo {
+	return &PaymentOperationRepo{store: store}
+}
+
+func (r *PaymentOperationRepo) GetByOrderId(ctx context.Context, orderUid string) ([]domain.PaymentOperation, error) {
+	var operations []domain.PaymentOperation
+	r.store.read(ctx, func(data *state) {
+		operations = slices.Clone(data.operations[orderUid])
+	})
+	if operations == nil {
+		operations = make([]domain.PaymentOperation, 0)
+	}
+	// Как ORDER BY performed_at, id: при равном времени сохраняется порядок добавления
+	sort.SliceStable(operations, func(i, j int) bool {
+		return operations[i].PerformedAt.Before(operations[j].PerformedAt)
+	})
+	return operations, nil
+}
+
+func (r *PaymentOperationRepo) Save(ctx context.Context, orderUid string, operation *domain.PaymentOperation) error {
+	return r.store.write(ctx, func(data *state) error {
+		if _, ok := data.orders[orderUid]; !ok {
+			return fmt.Errorf("save payment operation: %w: no order with uid %s", domain.ConflictError, orderUid)
+		}
+		if operationIndex(data.operations[orderUid], operation.Transaction) >= 0 {
+			return fmt.Errorf("save payment operation: %w: order %s already has operation %s", domain.ConflictError,
+				orderUid, operation.Transaction)
+		}
+		data.operations[orderUid] = append(data.operations[orderUid], *operation)
+		return nil
+	})
+}
+
+func (r *PaymentOperationRepo) UpdateStatus(ctx context.Context, orderUid, transaction string,
+	status domain.PaymentOperationStatus) error {
+	return r.store.write(ctx, func(data *state) error {
+		operations := data.operations[orderUid]
+		i := operationIndex(operations, transaction)
+		if i < 0 {
+			return fmt.Errorf("update payment operation %s of order %s: %w", transaction, orderUid,
+				domain.NotFoundError)
+		}
+		operations[i].Status = status
+		return nil
+	})
+}
+
+func operationIndex(operations []domain.PaymentOperation, transaction string) int {
+	return slices.IndexFunc(operations, func(op domain.PaymentOperation) bool { return op.Transaction == transaction })
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"web_service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepo struct {
+	store *Store
+}
+
+func NewPaymentRepo(store *Store) *PaymentRepo {
+	return &PaymentRepo{store: store}
+}
+
+func (r *PaymentRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Payment, error) {
+	var payment domain.Payment
+	var ok bool
+	r.store.read(ctx, func(data *state) {
+		payment, ok = data.payments[orderUid]
+	})
+	if !ok {
+		return nil, fmt.Errorf("get payment of order %s: %w", orderUid, domain.NotFoundError)
+	}
+	return &payment, nil
+}
+
+func (r *PaymentRepo) Save(ctx context.Context, payment *domain.Payment) error {
+	return r.store.write(ctx, func(data *state) error {
+		if _, ok := data.orders[payment.OrderUID]; !ok {
+			return fmt.Errorf("failed to save payment: %w: no order with uid %s", domain.ConflictError,
+				payment.OrderUID)
+		}
+		if _, ok := data.payments[payment.OrderUID]; ok {
+			return fmt.Errorf("failed to save payment: %w: order %s already has a payment", domain.ConflictError,
+				payment.OrderUID)
+		}
+		row := *payment
+		row.ID = uuid.NewString()
+		row.Operations, row.Totals, row.Reporting = nil, nil, nil
+		data.payments[payment.OrderUID] = row
+		return nil
+	})
+}
+
+func (r *PaymentRepo) Update(ctx context.Context, payment *domain.Payment) error {
+	return r.store.write(ctx, func(data *state) error {
+		existing, ok := data.payments[payment.OrderUID]
+		if !ok {
+			return fmt.Errorf("update payment of order %s: %w", payment.OrderUID, domain.NotFoundError)
+		}
+		row := *payment
+		row.ID = existing.ID
+		row.Operations, row.Totals, row.Reporting = nil, nil, nil
+		data.payments[payment.OrderUID] = row
+		return nil
+	})
+}
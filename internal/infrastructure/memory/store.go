@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+var ReadOnlyTransactionError = errors.New("cannot write in a read only transaction")
+
+// state — содержимое хранилища. Заказы хранятся без вложенных частей, как строки таблицы orders.
+type state struct {
+	orders map[string]domain.Order
+	// tracks связывает track_number с order_uid: номер уникален, на него ссылаются товары
+	tracks     map[string]string
+	deliveries map[string][]domain.Delivery
+	payments   map[string]domain.Payment
+	// operations — операции по оплате заказа в порядке добавления
+	operations map[string][]domain.PaymentOperation
+	items      map[string]domain.Item
+	// itemsByTrack хранит rid товаров заказа в порядке вставки
+	itemsByTrack map[string][]string
+	history      map[string][]domain.StatusChange
+}
+
+func newState() *state {
+	return &state{
+		orders:       make(map[string]domain.Order),
+		tracks:       make(map[string]string),
+		deliveries:   make(map[string][]domain.Delivery),
+		payments:     make(map[string]domain.Payment),
+		operations:   make(map[string][]domain.PaymentOperation),
+		items:        make(map[string]domain.Item),
+		itemsByTrack: make(map[string][]string),
+		history:      make(map[string][]domain.StatusChange),
+	}
+}
+
+// clone копирует состояние; срезы в значениях копируются, чтобы append в копии не менял оригинал
+func (s *state) clone() *state {
+	c := &state{
+		orders:       maps.Clone(s.orders),
+		tracks:       maps.Clone(s.tracks),
+		deliveries:   make(map[string][]domain.Delivery, len(s.deliveries)),
+		payments:     maps.Clone(s.payments),
+		operations:   make(map[string][]domain.PaymentOperation, len(s.operations)),
+		items:        maps.Clone(s.items),
+		itemsByTrack: make(map[string][]string, len(s.itemsByTrack)),
+		history:      make(map[string][]domain.StatusChange, len(s.history)),
+	}
+	for key, value := range s.deliveries {
+		c.deliveries[key] = slices.Clone(value)
+	}
+	for key, value := range s.operations {
+		c.operations[key] = slices.Clone(value)
+	}
+	for key, value := range s.itemsByTrack {
+		c.itemsByTrack[key] = slices.Clone(value)
+	}
+	for key, value := range s.history {
+		c.history[key] = slices.Clone(value)
+	}
+	return c
+}
+
+// Store — хранилище заказов в памяти процесса для тестов. Транзакции на запись работают с копией
+// состояния и выполняются по одной, поэтому откат просто отбрасывает копию, а коммит подменяет состояние.
+// Запись вне транзакции ведёт себя как отдельная транзакция.
+type Store struct {
+	// writer выстраивает транзакции на запись в очередь, как блокировка записи в SQLite
+	writer sync.Mutex
+	mu     sync.RWMutex
+	data   *state
+}
+
+func NewStore() *Store {
+	return &Store{data: newState()}
+}
+
+type txKey struct{}
+
+// tx — открытая транзакция: её копия состояния и настройки для вложенных вызовов
+type tx struct {
+	data     *state
+	readOnly bool
+	opts     protocols.TxOptions
+}
+
+func txFromContext(ctx context.Context) *tx {
+	t, _ := ctx.Value(txKey{}).(*tx)
+	return t
+}
+
+// read выполняет fn над снимком транзакции или над текущим состоянием
+func (s *Store) read(ctx context.Context, fn func(data *state)) {
+	if t := txFromContext(ctx); t != nil {
+		fn(t.data)
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.data)
+}
+
+// write выполняет fn в транзакции из ctx или в отдельной транзакции
+func (s *Store) write(ctx context.Context, fn func(data *state) error) error {
+	if t := txFromContext(ctx); t != nil {
+		if t.readOnly {
+			return ReadOnlyTransactionError
+		}
+		return fn(t.data)
+	}
+	s.writer.Lock()
+	defer s.writer.Unlock()
+	data := s.snapshot()
+	if err := fn(data); err != nil {
+		return err
+	}
+	s.commit(data)
+	return nil
+}
+
+func (s *Store) snapshot() *state {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.clone()
+}
+
+func (s *Store) commit(data *state) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUncommittedWritesAreInvisibleOutsideTransaction(t *testing.T) {
+	store := NewStore()
+	orders, tx := NewOrderRepo(store), NewTransactionManager(store)
+	order := &domain.Order{OrderUID: "uid", TrackNumber: "track", DateCreated: time.Now()}
+
+	err := tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, orders.Save(ctx, order))
+		_, err := orders.GetById(ctx, order.OrderUID)
+		assert.NoError(t, err, "transaction sees its own write")
+		_, err = orders.GetById(context.Background(), order.OrderUID)
+		assert.ErrorIs(t, err, domain.OrderNotFoundError, "other readers do not see uncommitted write")
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = orders.GetById(context.Background(), order.OrderUID)
+	assert.NoError(t, err)
+}
+
+func TestConcurrentTransactionsDoNotLoseWrites(t *testing.T) {
+	store := NewStore()
+	orders, tx := NewOrderRepo(store), NewTransactionManager(store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uid := strconv.Itoa(i)
+			assert.NoError(t, tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				return orders.Save(ctx, &domain.Order{OrderUID: uid, TrackNumber: "track-" + uid})
+			}))
+		}()
+	}
+	wg.Wait()
+
+	uids, err := orders.GetAllOrderUIDs(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, uids, 20)
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/persistent"
+	"web_service/internal/protocols"
+)
+
+// TransactionManager — менеджер транзакций Store. Каждая транзакция видит свой снимок, а транзакции
+// на запись выполняются по очереди, поэтому уровень изоляции из TxOptions ничего не меняет.
+type TransactionManager struct {
+	store *Store
+}
+
+func NewTransactionManager(store *Store) *TransactionManager {
+	return &TransactionManager{store: store}
+}
+
+func (tm *TransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return tm.WithinTransactionOptions(ctx, protocols.TxOptions{}, fn)
+}
+
+func (tm *TransactionManager) WithinTransactionOptions(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if outer := txFromContext(ctx); outer != nil {
+		return withinOuterTransaction(ctx, outer, opts, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := tm.run(ctx, opts, fn)
+		if err == nil || attempt >= opts.MaxRetries || !errors.Is(err, domain.RetryableError) {
+			return err
+		}
+		delay := persistent.RetryDelay(opts.RetryBackoff, attempt)
+		log.Printf("Transaction failed with retryable error, retry %d/%d in %s: %v\n",
+			attempt+1, opts.MaxRetries, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (tm *TransactionManager) run(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	t := &tx{readOnly: opts.AccessMode == protocols.AccessReadOnly, opts: opts}
+	if !t.readOnly {
+		tm.store.writer.Lock()
+		defer tm.store.writer.Unlock()
+	}
+	t.data = tm.store.snapshot()
+
+	if err := fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		return err
+	}
+	if !t.readOnly {
+		tm.store.commit(t.data)
+	}
+	return nil
+}
+
+// withinOuterTransaction выполняет вложенный вызов во внешней транзакции; savepoint — копия её состояния,
+// которая возвращается на место, если fn завершился ошибкой
+func withinOuterTransaction(ctx context.Context, outer *tx, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if err := persistent.CheckNestedOptions(outer.opts, opts); err != nil {
+		return err
+	}
+	if !opts.Savepoint {
+		return fn(ctx)
+	}
+	savepoint := outer.data.clone()
+	if err := fn(ctx); err != nil {
+		*outer.data = *savepoint
+		return err
+	}
+	return nil
+}
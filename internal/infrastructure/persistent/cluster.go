@@ -0,0 +1,167 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Таймаут проверки доступности реплики
+const replicaPingTimeout = 2 * time.Second
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// Cluster распределяет запросы между primary и репликами для чтения. Запись и транзакции на запись
+// идут в primary, чтения вне транзакций и read-only транзакции — по кругу в здоровые реплики.
+// Если по ключу (order_uid) недавно писали, чтения по нему в течение pinWindow идут в primary,
+// чтобы клиент увидел собственную запись несмотря на отставание реплик.
+type Cluster struct {
+	primary   *pgxpool.Pool
+	replicas  []*replica
+	next      atomic.Uint64
+	pinWindow time.Duration
+	// pins хранит время, до которого чтения ключа закреплены за primary
+	pins sync.Map
+}
+
+// NewCluster создаёт кластер; без реплик все запросы идут в primary. pinWindow = 0 отключает read-your-writes.
+func NewCluster(primary *pgxpool.Pool, replicas []*pgxpool.Pool, pinWindow time.Duration) *Cluster {
+	c := &Cluster{primary: primary, pinWindow: pinWindow}
+	for _, pool := range replicas {
+		connConfig := pool.Config().ConnConfig
+		r := &replica{name: fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port), pool: pool}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	return c
+}
+
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Querier возвращает транзакцию из контекста или primary
+func (c *Cluster) Querier(ctx context.Context) Querier {
+	if q := QuerierFromContext(ctx); q != nil {
+		return q
+	}
+	return c.primary
+}
+
+// ReadQuerier возвращает транзакцию из контекста, а вне транзакции — пул для чтения по ключу key
+func (c *Cluster) ReadQuerier(ctx context.Context, key string) Querier {
+	if q := QuerierFromContext(ctx); q != nil {
+		return q
+	}
+	return c.Reader(key)
+}
+
+// Reader выбирает пул для чтения: следующую здоровую реплику или primary, если ключ закреплён
+// или здоровых реплик нет
+func (c *Cluster) Reader(key string) *pgxpool.Pool {
+	if len(c.replicas) == 0 || c.pinned(key) {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return c.primary
+}
+
+// readPools возвращает пулы, между которыми Reader распределяет чтения незакреплённых ключей
+func (c *Cluster) readPools() []*pgxpool.Pool {
+	var pools []*pgxpool.Pool
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			pools = append(pools, r.pool)
+		}
+	}
+	if len(pools) == 0 {
+		return []*pgxpool.Pool{c.primary}
+	}
+	return pools
+}
+
+// Pin закрепляет чтения ключа за primary на pinWindow после записи
+func (c *Cluster) Pin(key string) {
+	if key == "" || c.pinWindow <= 0 || len(c.replicas) == 0 {
+		return
+	}
+	c.pins.Store(key, time.Now().Add(c.pinWindow))
+}
+
+// Written закрепляет ключ после записи вне транзакции; записи в транзакции закрепляет менеджер транзакций после коммита
+func (c *Cluster) Written(ctx context.Context, key string) {
+	if QuerierFromContext(ctx) == nil {
+		c.Pin(key)
+	}
+}
+
+func (c *Cluster) pinned(key string) bool {
+	if key == "" {
+		return false
+	}
+	value, ok := c.pins.Load(key)
+	return ok && time.Now().Before(value.(time.Time))
+}
+
+// CheckHealth пингует реплики и исключает недоступные из ротации до следующей успешной проверки
+func (c *Cluster) CheckHealth(ctx context.Context) {
+	for _, r := range c.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := r.pool.Ping(pingCtx)
+		cancel()
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("Read replica %s is back in rotation\n", r.name)
+			} else {
+				log.Printf("Read replica %s removed from rotation: %v\n", r.name, err)
+			}
+		}
+	}
+}
+
+// RunHealthChecks проверяет реплики каждые interval и заодно удаляет истёкшие закрепления, пока не отменён ctx
+func (c *Cluster) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.CheckHealth(ctx)
+			now := time.Now()
+			c.pins.Range(func(key, until any) bool {
+				if now.After(until.(time.Time)) {
+					c.pins.CompareAndDelete(key, until)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// Close закрывает пулы реплик; primary закрывает его владелец
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.pool.Close()
+	}
+}
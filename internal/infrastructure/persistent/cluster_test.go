@@ -0,0 +1,84 @@
+package persistent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lazyPool создаёт пул без подключения: соединения открываются только при первом запросе
+func lazyPool(t *testing.T, host string) *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), "postgres://user@"+host+":5432/db")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestClusterReaderRotatesHealthyReplicas(t *testing.T) {
+	primary := lazyPool(t, "primary")
+	first, second := lazyPool(t, "replica-1"), lazyPool(t, "replica-2")
+	cluster := NewCluster(primary, []*pgxpool.Pool{first, second}, time.Minute)
+
+	seen := map[*pgxpool.Pool]int{}
+	for i := 0; i < 4; i++ {
+		seen[cluster.Reader("uid")]++
+	}
+	assert.Equal(t, map[*pgxpool.Pool]int{first: 2, second: 2}, seen)
+
+	cluster.replicas[0].healthy.Store(false)
+	assert.Same(t, second, cluster.Reader("uid"))
+	assert.Same(t, second, cluster.Reader("uid"))
+
+	cluster.replicas[1].healthy.Store(false)
+	assert.Same(t, primary, cluster.Reader("uid"))
+}
+
+func TestClusterReadPoolsFollowReplicaHealth(t *testing.T) {
+	primary := lazyPool(t, "primary")
+	first, second := lazyPool(t, "replica-1"), lazyPool(t, "replica-2")
+	cluster := NewCluster(primary, []*pgxpool.Pool{first, second}, time.Minute)
+	probe := NewReadLoadProbe(cluster)
+
+	assert.Equal(t, []*pgxpool.Pool{first, second}, cluster.readPools())
+	assert.False(t, probe.Saturated())
+
+	cluster.replicas[0].healthy.Store(false)
+	assert.Equal(t, []*pgxpool.Pool{second}, cluster.readPools())
+
+	// Без здоровых реплик чтения и проверка нагрузки переходят на primary
+	cluster.replicas[1].healthy.Store(false)
+	assert.Equal(t, []*pgxpool.Pool{primary}, cluster.readPools())
+	assert.False(t, probe.Saturated())
+}
+
+func TestClusterPinsWrittenKeysToPrimary(t *testing.T) {
+	primary, replica := lazyPool(t, "primary"), lazyPool(t, "replica")
+	cluster := NewCluster(primary, []*pgxpool.Pool{replica}, time.Minute)
+
+	cluster.Written(context.Background(), "written")
+	assert.Same(t, primary, cluster.Reader("written"))
+	assert.Same(t, replica, cluster.Reader("other"))
+
+	// Запись внутри транзакции закрепляет менеджер транзакций после коммита
+	ctx := ContextWithQuerier(context.Background(), primary)
+	cluster.Written(ctx, "in-tx")
+	assert.Same(t, replica, cluster.Reader("in-tx"))
+
+	cluster.pins.Store("expired", time.Now().Add(-time.Second))
+	assert.Same(t, replica, cluster.Reader("expired"))
+}
+
+func TestClusterWithoutReplicasUsesPrimary(t *testing.T) {
+	primary := lazyPool(t, "primary")
+	cluster := NewCluster(primary, nil, time.Minute)
+
+	cluster.Pin("uid")
+	assert.Same(t, primary, cluster.Reader("uid"))
+	assert.Same(t, primary, cluster.Reader(""))
+	_, pinned := cluster.pins.Load("uid")
+	assert.False(t, pinned)
+}
@@ -0,0 +1,45 @@
+package persistent
+
+import (
+	"errors"
+	"fmt"
+	"web_service/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Коды SQLSTATE, которые переводятся в доменные категории ошибок
+const (
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// TranslateError переводит ошибки pgx и PostgreSQL в domain.NotFoundError, domain.ConflictError
+// и domain.RetryableError. Исходная ошибка остаётся в цепочке, остальные ошибки возвращаются как есть.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", domain.NotFoundError, err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation, foreignKeyViolation:
+			return fmt.Errorf("%w: %w", domain.ConflictError, err)
+		case serializationFailure, deadlockDetected:
+			return fmt.Errorf("%w: %w", domain.RetryableError, err)
+		}
+	}
+	return err
+}
+
+// IsUniqueViolation сообщает, нарушено ли уникальное ограничение constraint
+func IsUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
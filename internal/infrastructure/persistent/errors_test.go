@@ -0,0 +1,36 @@
+package persistent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"web_service/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	cases := map[string]struct {
+		err      error
+		category error
+	}{
+		"no rows":               {pgx.ErrNoRows, domain.NotFoundError},
+		"unique violation":      {&pgconn.PgError{Code: "23505"}, domain.ConflictError},
+		"foreign key violation": {&pgconn.PgError{Code: "23503"}, domain.ConflictError},
+		"serialization failure": {&pgconn.PgError{Code: "40001"}, domain.RetryableError},
+		"deadlock":              {fmt.Errorf("exec: %w", &pgconn.PgError{Code: "40P01"}), domain.RetryableError},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			translated := TranslateError(tc.err)
+			assert.ErrorIs(t, translated, tc.category)
+			assert.ErrorIs(t, translated, tc.err)
+		})
+	}
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, TranslateError(other))
+	assert.NoError(t, TranslateError(nil))
+}
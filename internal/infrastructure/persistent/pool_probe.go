@@ -0,0 +1,41 @@
+package persistent
+
+import "github.com/jackc/pgx/v5/pgxpool"
+
+// PoolLoadProbe считает пул насыщенным, когда все соединения заняты
+type PoolLoadProbe struct {
+	pool *pgxpool.Pool
+}
+
+func NewPoolLoadProbe(pool *pgxpool.Pool) *PoolLoadProbe {
+	return &PoolLoadProbe{pool: pool}
+}
+
+func (p *PoolLoadProbe) Saturated() bool {
+	return poolSaturated(p.pool)
+}
+
+// ReadLoadProbe оценивает пулы, куда кластер направляет чтения. Пока есть здоровые реплики, чтения идут в них,
+// и primary на результат не влияет; без здоровых реплик чтения уходят в primary, и проверяется он.
+type ReadLoadProbe struct {
+	cluster *Cluster
+}
+
+func NewReadLoadProbe(cluster *Cluster) *ReadLoadProbe {
+	return &ReadLoadProbe{cluster: cluster}
+}
+
+// Saturated сообщает о насыщении, когда заняты все пулы, куда могут уйти чтения
+func (p *ReadLoadProbe) Saturated() bool {
+	for _, pool := range p.cluster.readPools() {
+		if !poolSaturated(pool) {
+			return false
+		}
+	}
+	return true
+}
+
+func poolSaturated(pool *pgxpool.Pool) bool {
+	stat := pool.Stat()
+	return stat.AcquiredConns() >= stat.MaxConns()
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"testing"
+	"web_service/internal/infrastructure/persistent"
+	"web_service/internal/infrastructure/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Backend {
+		db := persistent.NewCluster(testPool(t), nil, 0)
+		return repotest.Backend{
+			Orders:     NewOrderRepo(db),
+			Deliveries: NewDeliveryRepo(db),
+			Payments:   NewPaymentRepo(db),
+			Operations: NewPaymentOperationRepo(db),
+			Items:      NewItemRepo(db),
+			Statuses:   NewOrderStatusRepo(db),
+			Tx:         persistent.NewPgxTransactionManager(db),
+		}
+	})
+}
@@ -6,27 +6,22 @@ import (
 	"log"
 	"web_service/internal/domain"
 	"web_service/internal/infrastructure/persistent"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DeliveryRepo struct {
-	pool *pgxpool.Pool
+	db *persistent.Cluster
 }
 
-func NewDeliveryRepo(pool *pgxpool.Pool) *DeliveryRepo {
-	return &DeliveryRepo{pool: pool}
+func NewDeliveryRepo(db *persistent.Cluster) *DeliveryRepo {
+	return &DeliveryRepo{db: db}
 }
 
 func (r *DeliveryRepo) getQuerier(ctx context.Context) persistent.Querier {
-	if q := persistent.QuerierFromContext(ctx); q != nil {
-		return q
-	}
-	return r.pool
+	return r.db.Querier(ctx)
 }
 
 func (r *DeliveryRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Delivery, error) {
-	querier := r.getQuerier(ctx)
+	querier := r.db.ReadQuerier(ctx, orderUid)
 
 	row := querier.QueryRow(ctx,
 		`SELECT order_uid, name, phone, zip, city, address, region, email
@@ -47,7 +42,7 @@ func (r *DeliveryRepo) GetByOrderId(ctx context.Context, orderUid string) (*doma
 		&delivery.Email,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("get payment database error: %w", err)
+		return nil, fmt.Errorf("get delivery of order %s: %w", orderUid, persistent.TranslateError(err))
 	}
 
 	return &delivery, nil
@@ -70,8 +65,33 @@ func (r *DeliveryRepo) Save(ctx context.Context, delivery *domain.Delivery) erro
 	)
 	if err != nil {
 		log.Printf("Save delivery error: %v", err)
-		return fmt.Errorf("failed to save delivery: %w", err)
+		return fmt.Errorf("failed to save delivery: %w", persistent.TranslateError(err))
+	}
+	r.db.Written(ctx, delivery.OrderUID)
+
+	return nil
+}
+
+func (r *DeliveryRepo) Update(ctx context.Context, delivery *domain.Delivery) error {
+	tag, err := r.getQuerier(ctx).Exec(ctx,
+		`UPDATE deliveries SET name = $2, phone = $3, zip = $4, city = $5, address = $6, region = $7, email = $8
+         WHERE order_uid = $1`,
+		delivery.OrderUID,
+		delivery.Name,
+		delivery.Phone,
+		delivery.Zip,
+		delivery.City,
+		delivery.Address,
+		delivery.Region,
+		delivery.Email,
+	)
+	if err != nil {
+		return fmt.Errorf("update delivery of order %s: %w", delivery.OrderUID, persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("update delivery of order %s: %w", delivery.OrderUID, domain.NotFoundError)
 	}
+	r.db.Written(ctx, delivery.OrderUID)
 
 	return nil
 }
@@ -7,26 +7,23 @@ import (
 	"web_service/internal/domain"
 	"web_service/internal/infrastructure/persistent"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 type ItemRepo struct {
-	pool *pgxpool.Pool
+	db *persistent.Cluster
 }
 
-func NewItemRepo(pool *pgxpool.Pool) *ItemRepo {
-	return &ItemRepo{pool: pool}
+func NewItemRepo(db *persistent.Cluster) *ItemRepo {
+	return &ItemRepo{db: db}
 }
 
 func (r *ItemRepo) getQuerier(ctx context.Context) persistent.Querier {
-	if q := persistent.QuerierFromContext(ctx); q != nil {
-		return q
-	}
-	return r.pool
+	return r.db.Querier(ctx)
 }
 
 func (r *ItemRepo) GetByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Item, error) {
-	querier := r.getQuerier(ctx)
+	querier := r.db.ReadQuerier(ctx, trackNumber)
 
 	rows, err := querier.Query(ctx,
 		`SELECT 
@@ -38,7 +35,7 @@ func (r *ItemRepo) GetByTrackNumber(ctx context.Context, trackNumber string) ([]
 	)
 	if err != nil {
 		log.Printf("get items database error: %v", err)
-		return nil, fmt.Errorf("failed to query items: %w", err)
+		return nil, fmt.Errorf("failed to query items: %w", persistent.TranslateError(err))
 	}
 	defer rows.Close()
 
@@ -67,20 +64,80 @@ func (r *ItemRepo) GetByTrackNumber(ctx context.Context, trackNumber string) ([]
 
 	if err = rows.Err(); err != nil {
 		log.Printf("items Rows iteration error: %v", err)
-		return nil, fmt.Errorf("error iterating items rows: %w", err)
+		return nil, fmt.Errorf("error iterating items rows: %w", persistent.TranslateError(err))
 	}
 
 	return items, nil
 }
 
+const insertItemSQL = `INSERT INTO items (
+            rid, track_number, chrt_id, price, name, sale, size, 
+            total_price, nm_id, brand, status
+         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
 func (r *ItemRepo) Save(ctx context.Context, item *domain.Item) error {
 	querier := r.getQuerier(ctx)
 
-	_, err := querier.Exec(ctx,
-		`INSERT INTO items (
-            rid, track_number, chrt_id, price, name, sale, size, 
-            total_price, nm_id, brand, status
-         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+	_, err := querier.Exec(ctx, insertItemSQL, itemArgs(item)...)
+	if err != nil {
+		log.Printf("failed to save item: %v", err)
+		return fmt.Errorf("failed to save item: %w", persistent.TranslateError(err))
+	}
+	r.db.Written(ctx, item.TrackNumber)
+
+	return nil
+}
+
+// SaveAll отправляет вставки одним pgx.Batch. В отличие от CopyFrom, у каждой вставки свой результат,
+// поэтому нарушение ограничения можно привязать к конкретному товару. Внутри транзакции первая ошибка
+// прерывает её, так что остальные вставки уже не выполняются.
+func (r *ItemRepo) SaveAll(ctx context.Context, items []domain.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for i := range items {
+		batch.Queue(insertItemSQL, itemArgs(&items[i])...)
+	}
+
+	results := r.getQuerier(ctx).SendBatch(ctx, batch)
+	for i := range items {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			log.Printf("failed to save item %s: %v", items[i].Rid, err)
+			return fmt.Errorf("failed to save items: %w", &domain.ItemSaveError{Index: i, Rid: items[i].Rid,
+				Err: persistent.TranslateError(err)})
+		}
+	}
+	if err := results.Close(); err != nil {
+		log.Printf("failed to save items: %v", err)
+		return fmt.Errorf("failed to save items: %w", persistent.TranslateError(err))
+	}
+
+	for i := range items {
+		r.db.Written(ctx, items[i].TrackNumber)
+	}
+
+	return nil
+}
+
+func (r *ItemRepo) Delete(ctx context.Context, trackNumber, rid string) error {
+	tag, err := r.getQuerier(ctx).Exec(ctx,
+		`DELETE FROM items WHERE track_number = $1 AND rid = $2`, trackNumber, rid)
+	if err != nil {
+		return fmt.Errorf("delete item %s: %w", rid, persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete item %s: %w: no such item in order with track number %s",
+			rid, domain.NotFoundError, trackNumber)
+	}
+	r.db.Written(ctx, trackNumber)
+
+	return nil
+}
+
+func itemArgs(item *domain.Item) []any {
+	return []any{
 		item.Rid,
 		item.TrackNumber,
 		item.ChrtID,
@@ -92,11 +149,5 @@ func (r *ItemRepo) Save(ctx context.Context, item *domain.Item) error {
 		item.NmID,
 		item.Brand,
 		item.Status,
-	)
-	if err != nil {
-		log.Printf("failed to save item: %v", err)
-		return fmt.Errorf("failed to save item: %w", err)
 	}
-
-	return nil
 }
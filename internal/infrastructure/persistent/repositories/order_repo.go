@@ -2,46 +2,45 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 	"web_service/internal/domain"
 	"web_service/internal/infrastructure/persistent"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 type OrderRepo struct {
-	pool *pgxpool.Pool
+	db *persistent.Cluster
 }
 
-func NewOrderRepo(pool *pgxpool.Pool) *OrderRepo {
-	return &OrderRepo{pool: pool}
+func NewOrderRepo(db *persistent.Cluster) *OrderRepo {
+	return &OrderRepo{db: db}
 }
 
 func (r *OrderRepo) getQuerier(ctx context.Context) persistent.Querier {
-	if q := persistent.QuerierFromContext(ctx); q != nil {
-		return q
-	}
-	return r.pool
+	return r.db.Querier(ctx)
 }
 
 func (r *OrderRepo) GetById(ctx context.Context, orderUid string) (*domain.Order, error) {
-	querier := r.getQuerier(ctx)
+	querier := r.db.ReadQuerier(ctx, orderUid)
 	row := querier.QueryRow(
 		ctx,
 		`select order_uid, track_number, entry, locale, internal_signature,
 				customer_id, delivery_service, shardkey, sm_id, date_created,
-				oof_shard from orders where order_uid = $1`,
+				oof_shard, status, version, updated_at from orders where order_uid = $1`,
 		orderUid)
 	var order domain.Order
 	err := row.Scan(&order.OrderUID, &order.TrackNumber, &order.Entry,
 		&order.Locale, &order.InternalSignature,
 		&order.CustomerID, &order.DeliveryService, &order.Shardkey,
-		&order.SmID, &order.DateCreated, &order.OofShard)
+		&order.SmID, &order.DateCreated, &order.OofShard, &order.Status, &order.Version, &order.UpdatedAt)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
 		}
-		return nil, fmt.Errorf("database error: %w", err)
+		return nil, fmt.Errorf("get order %s: %w", orderUid, persistent.TranslateError(err))
 	}
 
 	return &order, nil
@@ -52,27 +51,72 @@ func (r *OrderRepo) Save(ctx context.Context, order *domain.Order) error {
 	_, err := querier.Exec(ctx,
 		`INSERT INTO orders (
             order_uid, track_number, entry, locale, internal_signature,
-            customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+            customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, status, version, updated_at
+         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
 		order.OrderUID, order.TrackNumber, order.Entry,
 		order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.Shardkey,
-		order.SmID, order.DateCreated, order.OofShard,
+		order.SmID, order.DateCreated, order.OofShard, order.Status, order.Version, order.UpdatedAt,
 	)
 	if err != nil {
-		return fmt.Errorf("save order: %w", err)
+		// Заказ с тем же uid успел сохранить параллельный обработчик
+		if persistent.IsUniqueViolation(err, "orders_pkey") {
+			return fmt.Errorf("%w: %w", domain.OrderAlreadyExistsError, err)
+		}
+		return fmt.Errorf("save order: %w", persistent.TranslateError(err))
 	}
+	r.db.Written(ctx, order.OrderUID)
 
 	return nil
+}
+
+// UpdateVersion — compare-and-set по версии, как OrderStatusRepo.UpdateStatus по статусу
+func (r *OrderRepo) UpdateVersion(ctx context.Context, orderUid string, version int) error {
+	querier := r.getQuerier(ctx)
+	tag, err := querier.Exec(ctx,
+		`UPDATE orders SET version = version + 1, updated_at = clock_timestamp()
+         WHERE order_uid = $1 AND version = $2`,
+		orderUid, version)
+	if err != nil {
+		return fmt.Errorf("update version of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		var current int
+		err := querier.QueryRow(ctx, `SELECT version FROM orders WHERE order_uid = $1`, orderUid).Scan(&current)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+		}
+		if err != nil {
+			return fmt.Errorf("update version of order %s: %w", orderUid, persistent.TranslateError(err))
+		}
+		return fmt.Errorf("%w: order %s changed concurrently, version %d instead of %d",
+			domain.RetryableError, orderUid, current, version)
+	}
+	r.db.Written(ctx, orderUid)
 
+	return nil
 }
 
 func (r *OrderRepo) GetAllOrderUIDs(ctx context.Context) ([]string, error) {
-	querier := r.getQuerier(ctx)
+	querier := r.db.ReadQuerier(ctx, "")
 	rows, err := querier.Query(ctx, `SELECT order_uid FROM orders`)
 	if err != nil {
-		return nil, fmt.Errorf("could not load all order uids, database error: %w", err)
+		return nil, fmt.Errorf("could not load all order uids, database error: %w", persistent.TranslateError(err))
+	}
+	return scanOrderUIDs(rows)
+}
+
+func (r *OrderRepo) GetOrderUIDsUpdatedAfter(ctx context.Context, since time.Time) ([]string, error) {
+	querier := r.db.ReadQuerier(ctx, "")
+	rows, err := querier.Query(ctx, `SELECT order_uid FROM orders WHERE updated_at > $1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("could not load order uids updated after %s, database error: %w", since,
+			persistent.TranslateError(err))
 	}
+	return scanOrderUIDs(rows)
+}
+
+func scanOrderUIDs(rows pgx.Rows) ([]string, error) {
 	defer rows.Close()
 	result := make([]string, 0)
 	for rows.Next() {
@@ -82,5 +126,8 @@ func (r *OrderRepo) GetAllOrderUIDs(ctx context.Context) ([]string, error) {
 		}
 		result = append(result, orderUID)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order uid rows: %w", persistent.TranslateError(err))
+	}
 	return result, nil
 }
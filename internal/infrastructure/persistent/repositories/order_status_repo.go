@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/persistent"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OrderStatusRepo struct {
+	db *persistent.Cluster
+}
+
+func NewOrderStatusRepo(db *persistent.Cluster) *OrderStatusRepo {
+	return &OrderStatusRepo{db: db}
+}
+
+// UpdateStatus меняет статус compare-and-set'ом и обновляет updated_at: в READ COMMITTED параллельная транзакция, успевшая сменить
+// статус первой, оставит UPDATE без строк, и вызывающий получит domain.RetryableError
+func (r *OrderStatusRepo) UpdateStatus(ctx context.Context, orderUid string, from, to domain.OrderStatus) error {
+	querier := r.db.Querier(ctx)
+	tag, err := querier.Exec(ctx,
+		`UPDATE orders SET status = $3, updated_at = clock_timestamp() WHERE order_uid = $1 AND status = $2`,
+		orderUid, from, to)
+	if err != nil {
+		return fmt.Errorf("update status of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		var current domain.OrderStatus
+		err := querier.QueryRow(ctx, `SELECT status FROM orders WHERE order_uid = $1`, orderUid).Scan(&current)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+		}
+		if err != nil {
+			return fmt.Errorf("update status of order %s: %w", orderUid, persistent.TranslateError(err))
+		}
+		return fmt.Errorf("%w: status of order %s changed concurrently from %s to %s",
+			domain.RetryableError, orderUid, from, current)
+	}
+	r.db.Written(ctx, orderUid)
+
+	return nil
+}
+
+func (r *OrderStatusRepo) AddHistory(ctx context.Context, orderUid string, change domain.StatusChange) error {
+	_, err := r.db.Querier(ctx).Exec(ctx,
+		`INSERT INTO order_status_history (order_uid, status, changed_at) VALUES ($1, $2, $3)`,
+		orderUid, change.Status, change.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("add status history of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	r.db.Written(ctx, orderUid)
+
+	return nil
+}
+
+func (r *OrderStatusRepo) GetHistory(ctx context.Context, orderUid string) ([]domain.StatusChange, error) {
+	rows, err := r.db.ReadQuerier(ctx, orderUid).Query(ctx,
+		`SELECT status, changed_at FROM order_status_history WHERE order_uid = $1 ORDER BY changed_at, id`,
+		orderUid)
+	if err != nil {
+		return nil, fmt.Errorf("get status history of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	defer rows.Close()
+
+	history := make([]domain.StatusChange, 0)
+	for rows.Next() {
+		var change domain.StatusChange
+		if err := rows.Scan(&change.Status, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("scan status history of order %s: %w", orderUid, err)
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get status history of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	return history, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/persistent"
+)
+
+type PaymentOperationRepo struct {
+	db *persistent.Cluster
+}
+
+func NewPaymentOperationRepo(db *persistent.Cluster) *PaymentOperationRepo {
+	return &PaymentOperationRepo{db: db}
+}
+
+func (r *PaymentOperationRepo) GetByOrderId(ctx context.Context, orderUid string) ([]domain.PaymentOperation, error) {
+	rows, err := r.db.ReadQuerier(ctx, orderUid).Query(ctx,
+		`SELECT transaction, type, status, amount, currency, provider, performed_at
+         FROM payment_operations WHERE order_uid = $1 ORDER BY performed_at, id`,
+		orderUid)
+	if err != nil {
+		return nil, fmt.Errorf("get payment operations of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	defer rows.Close()
+
+	operations := make([]domain.PaymentOperation, 0)
+	for rows.Next() {
+		var op domain.PaymentOperation
+		if err := rows.Scan(&op.Transaction, &op.Type, &op.Status, &op.Amount, &op.Currency, &op.Provider,
+			&op.PerformedAt); err != nil {
+			return nil, fmt.Errorf("scan payment operations of order %s: %w", orderUid, err)
+		}
+		operations = append(operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get payment operations of order %s: %w", orderUid, persistent.TranslateError(err))
+	}
+	return operations, nil
+}
+
+func (r *PaymentOperationRepo) Save(ctx context.Context, orderUid string, operation *domain.PaymentOperation) error {
+	_, err := r.db.Querier(ctx).Exec(ctx,
+		`INSERT INTO payment_operations
+            (order_uid, transaction, type, status, amount, currency, provider, performed_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		orderUid, operation.Transaction, operation.Type, operation.Status, operation.Amount,
+		operation.Currency, operation.Provider, operation.PerformedAt)
+	if err != nil {
+		return fmt.Errorf("save payment operation %s of order %s: %w", operation.Transaction, orderUid,
+			persistent.TranslateError(err))
+	}
+	r.db.Written(ctx, orderUid)
+
+	return nil
+}
+
+func (r *PaymentOperationRepo) UpdateStatus(ctx context.Context, orderUid, transaction string,
+	status domain.PaymentOperationStatus) error {
+	tag, err := r.db.Querier(ctx).Exec(ctx,
+		`UPDATE payment_operations SET status = $3 WHERE order_uid = $1 AND transaction = $2`,
+		orderUid, transaction, status)
+	if err != nil {
+		return fmt.Errorf("update payment operation %s of order %s: %w", transaction, orderUid,
+			persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("update payment operation %s of order %s: %w", transaction, orderUid,
+			domain.NotFoundError)
+	}
+	r.db.Written(ctx, orderUid)
+
+	return nil
+}
@@ -6,40 +6,35 @@ import (
 	"log"
 	"web_service/internal/domain"
 	"web_service/internal/infrastructure/persistent"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PaymentRepo struct {
-	pool *pgxpool.Pool
+	db *persistent.Cluster
 }
 
 func (r *PaymentRepo) getQuerier(ctx context.Context) persistent.Querier {
-	if q := persistent.QuerierFromContext(ctx); q != nil {
-		return q
-	}
-	return r.pool
+	return r.db.Querier(ctx)
 }
 
-func NewPaymentRepo(pool *pgxpool.Pool) *PaymentRepo {
-	return &PaymentRepo{pool: pool}
+func NewPaymentRepo(db *persistent.Cluster) *PaymentRepo {
+	return &PaymentRepo{db: db}
 }
 
-func (r *PaymentRepo) GetByTransactionId(ctx context.Context, transaction string) (*domain.Payment, error) {
-	querier := r.getQuerier(ctx)
+func (r *PaymentRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Payment, error) {
+	querier := r.db.ReadQuerier(ctx, orderUid)
 	row := querier.QueryRow(
 		ctx,
-		"select id, transaction, request_id, currency, provider, amount, payment_dt, bank,"+
+		"select id, order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank,"+
 			"delivery_cost, goods_total, custom_fee"+
-			" from payments where transaction = $1",
-		transaction)
+			" from payments where order_uid = $1",
+		orderUid)
 	var payment domain.Payment
-	err := row.Scan(&payment.ID, &payment.Transaction, &payment.RequestID,
+	err := row.Scan(&payment.ID, &payment.OrderUID, &payment.Transaction, &payment.RequestID,
 		&payment.Currency, &payment.Provider,
 		&payment.Amount, &payment.PaymentDt, &payment.Bank, &payment.DeliveryCost,
 		&payment.GoodsTotal, &payment.CustomFee)
 	if err != nil {
-		return nil, fmt.Errorf("database error: %w", err)
+		return nil, fmt.Errorf("get payment of order %s: %w", orderUid, persistent.TranslateError(err))
 	}
 	return &payment, nil
 }
@@ -49,9 +44,10 @@ func (r *PaymentRepo) Save(ctx context.Context, payment *domain.Payment) error {
 
 	_, err := querier.Exec(ctx,
 		`INSERT INTO payments (
-            transaction, request_id, currency, provider, amount, payment_dt, bank, 
+            order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank,
             delivery_cost, goods_total, custom_fee
-         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		payment.OrderUID,
 		payment.Transaction,
 		payment.RequestID,
 		payment.Currency,
@@ -65,8 +61,37 @@ func (r *PaymentRepo) Save(ctx context.Context, payment *domain.Payment) error {
 	)
 	if err != nil {
 		log.Printf("Save payment error: %v\n", err)
-		return fmt.Errorf("failed to save payment: %w", err)
+		return fmt.Errorf("failed to save payment: %w", persistent.TranslateError(err))
+	}
+	r.db.Written(ctx, payment.OrderUID)
+
+	return nil
+}
+
+func (r *PaymentRepo) Update(ctx context.Context, payment *domain.Payment) error {
+	tag, err := r.getQuerier(ctx).Exec(ctx,
+		`UPDATE payments SET transaction = $2, request_id = $3, currency = $4, provider = $5, amount = $6,
+            payment_dt = $7, bank = $8, delivery_cost = $9, goods_total = $10, custom_fee = $11
+         WHERE order_uid = $1`,
+		payment.OrderUID,
+		payment.Transaction,
+		payment.RequestID,
+		payment.Currency,
+		payment.Provider,
+		payment.Amount,
+		payment.PaymentDt,
+		payment.Bank,
+		payment.DeliveryCost,
+		payment.GoodsTotal,
+		payment.CustomFee,
+	)
+	if err != nil {
+		return fmt.Errorf("update payment of order %s: %w", payment.OrderUID, persistent.TranslateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("update payment of order %s: %w", payment.OrderUID, domain.NotFoundError)
 	}
+	r.db.Written(ctx, payment.OrderUID)
 
 	return nil
 }
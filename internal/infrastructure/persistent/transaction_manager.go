@@ -3,24 +3,66 @@ package persistent
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Пауза перед повтором, если в TxOptions она не задана
+const defaultRetryBackoff = 20 * time.Millisecond
+
+var IncompatibleTransactionError = errors.New("nested transaction options are incompatible with the outer transaction")
+
+// PgxTransactionManager открывает транзакции на запись в primary, а read-only — в реплике, если они настроены
 type PgxTransactionManager struct {
-	pool *pgxpool.Pool
+	db *Cluster
 }
 
-func NewPgxTransactionManager(pool *pgxpool.Pool) *PgxTransactionManager {
-	return &PgxTransactionManager{pool: pool}
+func NewPgxTransactionManager(db *Cluster) *PgxTransactionManager {
+	return &PgxTransactionManager{db: db}
 }
 
 func (tm *PgxTransactionManager) WithinTransaction(ctx context.Context,
 	fn func(ctx context.Context) error) error {
-	tx, err := tm.pool.Begin(ctx)
+	return tm.WithinTransactionOptions(ctx, protocols.TxOptions{}, fn)
+}
+
+func (tm *PgxTransactionManager) WithinTransactionOptions(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if outer, ok := QuerierFromContext(ctx).(pgx.Tx); ok {
+		return withinOuterTransaction(ctx, outer, opts, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := tm.run(ctx, opts, fn)
+		if err == nil || attempt >= opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+		delay := RetryDelay(opts.RetryBackoff, attempt)
+		log.Printf("Transaction failed with retryable error, retry %d/%d in %s: %v\n",
+			attempt+1, opts.MaxRetries, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (tm *PgxTransactionManager) run(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	tx, err := tm.pool(opts).BeginTx(ctx, toPgxTxOptions(opts))
 	if err != nil {
-		return err
+		return fmt.Errorf("begin transaction: %w", TranslateError(err))
 	}
 
 	defer func(tx pgx.Tx, ctx context.Context) {
@@ -30,12 +72,105 @@ func (tm *PgxTransactionManager) WithinTransaction(ctx context.Context,
 		}
 	}(tx, ctx)
 
-	// Устанавливаем tx в контекст как Querier
-	ctx = ContextWithQuerier(ctx, tx)
+	// Устанавливаем tx в контекст как Querier вместе с его настройками для вложенных вызовов
+	ctx = contextWithTxOptions(ContextWithQuerier(ctx, tx), opts)
 
 	if err := fn(ctx); err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", TranslateError(err))
+	}
+	if opts.AccessMode != protocols.AccessReadOnly {
+		tm.db.Pin(opts.RoutingKey)
+	}
+	return nil
+}
+
+// pool выбирает, где открыть транзакцию. SERIALIZABLE недоступен на hot standby, поэтому такие
+// транзакции всегда идут в primary.
+func (tm *PgxTransactionManager) pool(opts protocols.TxOptions) *pgxpool.Pool {
+	if opts.AccessMode == protocols.AccessReadOnly && opts.Isolation != protocols.IsolationSerializable {
+		return tm.db.Reader(opts.RoutingKey)
+	}
+	return tm.db.Primary()
+}
+
+// withinOuterTransaction выполняет вложенный вызов в уже открытой транзакции. Изоляцию и режим доступа
+// у открытой транзакции не поменять, поэтому несовместимые настройки — ошибка, а не тихое игнорирование.
+func withinOuterTransaction(ctx context.Context, outer pgx.Tx, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if err := CheckNestedOptions(txOptionsFromContext(ctx), opts); err != nil {
+		return err
+	}
+	if !opts.Savepoint {
+		return fn(ctx)
+	}
+
+	savepoint, err := outer.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create savepoint: %w", TranslateError(err))
+	}
+	defer func() {
+		if err := savepoint.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Printf("Failed to roll back to savepoint: %v\n", err)
+		}
+	}()
+	if err := fn(ContextWithQuerier(ctx, savepoint)); err != nil {
+		return err
+	}
+	if err := savepoint.Commit(ctx); err != nil {
+		return fmt.Errorf("release savepoint: %w", TranslateError(err))
+	}
+	return nil
+}
+
+// CheckNestedOptions проверяет, что вложенный вызов можно выполнить во внешней транзакции с настройками outer
+func CheckNestedOptions(outer, inner protocols.TxOptions) error {
+	if inner.Isolation != protocols.IsolationDefault && inner.Isolation != effectiveIsolation(outer.Isolation) {
+		return fmt.Errorf("%w: isolation %q inside %q", IncompatibleTransactionError, inner.Isolation, outer.Isolation)
+	}
+	if inner.AccessMode == protocols.AccessReadWrite && outer.AccessMode == protocols.AccessReadOnly {
+		return fmt.Errorf("%w: read write inside read only", IncompatibleTransactionError)
+	}
+	return nil
+}
+
+// effectiveIsolation раскрывает уровень по умолчанию: у PostgreSQL это read committed
+func effectiveIsolation(isolation protocols.IsolationLevel) protocols.IsolationLevel {
+	if isolation == protocols.IsolationDefault {
+		return protocols.IsolationReadCommitted
+	}
+	return isolation
+}
+
+func toPgxTxOptions(opts protocols.TxOptions) pgx.TxOptions {
+	txOptions := pgx.TxOptions{
+		IsoLevel:   pgx.TxIsoLevel(opts.Isolation),
+		AccessMode: pgx.TxAccessMode(opts.AccessMode),
+	}
+	if opts.Deferrable {
+		txOptions.DeferrableMode = pgx.Deferrable
+	}
+	return txOptions
+}
+
+// isRetryable узнаёт ошибки сериализации и deadlock, даже если репозиторий не перевёл их в domain.RetryableError
+func isRetryable(err error) bool {
+	if errors.Is(err, domain.RetryableError) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+// RetryDelay удваивает паузу с каждой попыткой и добавляет случайную добавку, чтобы конкурирующие
+// транзакции не повторялись синхронно
+func RetryDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	delay := backoff << min(attempt, 10)
+	return delay + rand.N(delay/2+1)
 }
@@ -0,0 +1,60 @@
+package persistent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPgxTxOptions(t *testing.T) {
+	opts := toPgxTxOptions(protocols.TxOptions{
+		Isolation:  protocols.IsolationSerializable,
+		AccessMode: protocols.AccessReadOnly,
+		Deferrable: true,
+	})
+	assert.Equal(t, pgx.Serializable, opts.IsoLevel)
+	assert.Equal(t, pgx.ReadOnly, opts.AccessMode)
+	assert.Equal(t, pgx.Deferrable, opts.DeferrableMode)
+	assert.Equal(t, pgx.TxOptions{}, toPgxTxOptions(protocols.TxOptions{}))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, isRetryable(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"})))
+	assert.True(t, isRetryable(fmt.Errorf("save: %w", domain.RetryableError)))
+	assert.False(t, isRetryable(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, isRetryable(domain.OrderNotFoundError))
+}
+
+func TestRetryDelayGrows(t *testing.T) {
+	first := RetryDelay(10*time.Millisecond, 0)
+	assert.GreaterOrEqual(t, first, 10*time.Millisecond)
+	assert.LessOrEqual(t, first, 15*time.Millisecond)
+	third := RetryDelay(10*time.Millisecond, 2)
+	assert.GreaterOrEqual(t, third, 40*time.Millisecond)
+}
+
+func TestNestedOptionsMustMatchOuterTransaction(t *testing.T) {
+	outer := protocols.TxOptions{Isolation: protocols.IsolationRepeatableRead, AccessMode: protocols.AccessReadOnly}
+
+	assert.NoError(t, CheckNestedOptions(outer, protocols.TxOptions{}))
+	assert.NoError(t, CheckNestedOptions(outer, protocols.TxOptions{Isolation: protocols.IsolationRepeatableRead}))
+	assert.ErrorIs(t, CheckNestedOptions(outer, protocols.TxOptions{Isolation: protocols.IsolationSerializable}),
+		IncompatibleTransactionError)
+	assert.ErrorIs(t, CheckNestedOptions(outer, protocols.TxOptions{AccessMode: protocols.AccessReadWrite}),
+		IncompatibleTransactionError)
+}
+
+func TestNestedOptionsTreatDefaultAsReadCommitted(t *testing.T) {
+	outer := protocols.TxOptions{}
+
+	assert.NoError(t, CheckNestedOptions(outer, protocols.TxOptions{Isolation: protocols.IsolationReadCommitted}))
+	assert.ErrorIs(t, CheckNestedOptions(outer, protocols.TxOptions{Isolation: protocols.IsolationRepeatableRead}),
+		IncompatibleTransactionError)
+}
@@ -2,6 +2,7 @@ package persistent
 
 import (
 	"context"
+	"web_service/internal/protocols"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -9,11 +10,14 @@ import (
 
 type querierKey struct{}
 
+type txOptionsKey struct{}
+
 // Querier — интерфейс, общий для *pgxpool.Pool и pgx.Tx
 type Querier interface {
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
 }
 
 func ContextWithQuerier(ctx context.Context, q Querier) context.Context {
@@ -26,3 +30,12 @@ func QuerierFromContext(ctx context.Context) Querier {
 	}
 	return nil
 }
+
+func contextWithTxOptions(ctx context.Context, opts protocols.TxOptions) context.Context {
+	return context.WithValue(ctx, txOptionsKey{}, opts)
+}
+
+func txOptionsFromContext(ctx context.Context) protocols.TxOptions {
+	opts, _ := ctx.Value(txOptionsKey{}).(protocols.TxOptions)
+	return opts
+}
@@ -0,0 +1,41 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"web_service/internal/domain"
+)
+
+// ratesFile — формат файла курсов: сколько единиц каждой валюты дают за одну единицу base
+type ratesFile struct {
+	Base  domain.Currency                 `json:"base"`
+	Rates map[domain.Currency]json.Number `json:"rates"`
+}
+
+// LoadExchangeRatesFile читает курсы валют из JSON-файла. Курсы разбираются как десятичные дроби
+// без перевода во float, например 0.0108 хранится точно.
+func LoadExchangeRatesFile(path string) (*domain.ExchangeRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exchange rates file: %w", err)
+	}
+	var file ratesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse exchange rates file: %w", err)
+	}
+	parsed := make(map[domain.Currency]*big.Rat, len(file.Rates))
+	for currency, value := range file.Rates {
+		rate, ok := new(big.Rat).SetString(value.String())
+		if !ok {
+			return nil, fmt.Errorf("exchange rate of %s: invalid number %q", currency, value)
+		}
+		parsed[currency] = rate
+	}
+	rates, err := domain.NewExchangeRates(file.Base, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rates file: %w", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,471 @@
+// Package repotest — общий набор тестов для реализаций репозиториев и менеджера транзакций.
+// Каждый бэкенд хранения запускает его из своих тестов, чтобы поведение реализаций не расходилось.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/persistent"
+	"web_service/internal/protocols"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend — проверяемая реализация хранилища заказов
+type Backend struct {
+	Orders     protocols.OrderRepoInterface
+	Deliveries protocols.DeliveryRepoInterface
+	Payments   protocols.PaymentRepoInterface
+	Operations protocols.PaymentOperationRepoInterface
+	Items      protocols.ItemRepoInterface
+	Statuses   protocols.OrderStatusRepoInterface
+	Tx         protocols.TransactionManagerInterface
+}
+
+var rollbackError = errors.New("rollback requested by test")
+
+// Run запускает набор на бэкенде, который создаёт newBackend. БД может быть общей для тестов
+// и не пустой: каждый тест работает только со своими заказами со случайными uid.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	tests := map[string]func(t *testing.T, b Backend){
+		"SavedOrderIsReadBack":             testSavedOrderIsReadBack,
+		"MissingOrderIsNotFound":           testMissingOrderIsNotFound,
+		"DuplicateOrderIsConflict":         testDuplicateOrderIsConflict,
+		"ItemOfUnknownOrderIsConflict":     testItemOfUnknownOrderIsConflict,
+		"FailingItemInBatchIsReported":     testFailingItemInBatchIsReported,
+		"RollbackDiscardsWrites":           testRollbackDiscardsWrites,
+		"SavepointRollbackKeepsOuterWrite": testSavepointRollbackKeepsOuterWrite,
+		"ReadOnlyTransactionRejectsWrites": testReadOnlyTransactionRejectsWrites,
+		"OrderUIDsUpdatedAfter":            testOrderUIDsUpdatedAfter,
+		"StatusChangeWithHistory":          testStatusChangeWithHistory,
+		"StaleStatusChangeIsRetryable":     testStaleStatusChangeIsRetryable,
+		"VersionIsCompareAndSet":           testVersionIsCompareAndSet,
+		"UpdatesAreReadBack":               testUpdatesAreReadBack,
+		"UpdateOfMissingRowIsNotFound":     testUpdateOfMissingRowIsNotFound,
+		"SecondPaymentOfOrderIsConflict":   testSecondPaymentOfOrderIsConflict,
+		"PaymentOperations":                testPaymentOperations,
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newBackend(t))
+		})
+	}
+}
+
+// NewOrder возвращает заполненный заказ с уникальными order_uid, track_number и rid
+func NewOrder(dateCreated time.Time) *domain.Order {
+	id := uuid.NewString()
+	track := "TRACK-" + id[:8]
+	return &domain.Order{
+		OrderUID:    id,
+		TrackNumber: track,
+		Entry:       "WBIL",
+		Delivery: domain.Delivery{
+			OrderUID: id, Name: "Test Testov", Phone: "+9720000000", Zip: "2639809",
+			City: "Kiryat Mozkin", Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: domain.Payment{
+			OrderUID: id, Transaction: "pay-" + id[:8], Currency: "USD", Provider: "wbpay", Amount: 1917,
+			PaymentDt: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 417, CustomFee: 0,
+		},
+		Items: []domain.Item{
+			{Rid: id[:8] + "-1", ChrtID: 9934930, TrackNumber: track, Price: 453, Name: "Mascaras", Sale: 30,
+				Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+			{Rid: id[:8] + "-2", ChrtID: 9934931, TrackNumber: track, Price: 100, Name: "Brush", Sale: 0,
+				Size: "1", TotalPrice: 100, NmID: 2389213, Brand: "Vivienne Sabo", Status: 202},
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     dateCreated,
+		OofShard:        "1",
+		Status:          domain.StatusCreated,
+		Version:         1,
+		UpdatedAt:       dateCreated,
+	}
+}
+
+// SaveOrder сохраняет заказ со всеми частями в одной транзакции
+func SaveOrder(ctx context.Context, b Backend, order *domain.Order) error {
+	return b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.Orders.Save(ctx, order); err != nil {
+			return err
+		}
+		if err := b.Deliveries.Save(ctx, &order.Delivery); err != nil {
+			return err
+		}
+		if err := b.Payments.Save(ctx, &order.Payment); err != nil {
+			return err
+		}
+		return b.Items.SaveAll(ctx, order.Items)
+	})
+}
+
+func testSavedOrderIsReadBack(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Date(2021, 11, 26, 6, 22, 19, 123456000, time.UTC))
+	// История статусов хранится отдельно и строкой заказа не сохраняется
+	order.StatusHistory = []domain.StatusChange{{Status: domain.StatusCreated, ChangedAt: order.DateCreated}}
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	stored, err := b.Orders.GetById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.True(t, order.DateCreated.Equal(stored.DateCreated), "date_created %s, want %s",
+		stored.DateCreated, order.DateCreated)
+	assert.True(t, order.UpdatedAt.Equal(stored.UpdatedAt), "updated_at %s, want %s",
+		stored.UpdatedAt, order.UpdatedAt)
+	stored.DateCreated, stored.UpdatedAt = order.DateCreated, order.UpdatedAt
+	want := *order
+	want.Delivery, want.Payment, want.Items, want.StatusHistory = domain.Delivery{}, domain.Payment{}, nil, nil
+	assert.Equal(t, want, *stored)
+
+	delivery, err := b.Deliveries.GetByOrderId(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, order.Delivery, *delivery)
+
+	payment, err := b.Payments.GetByOrderId(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, payment.ID)
+	payment.ID = ""
+	assert.Equal(t, order.Payment, *payment)
+
+	items, err := b.Items.GetByTrackNumber(ctx, order.TrackNumber)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, order.Items, items)
+}
+
+func testMissingOrderIsNotFound(t *testing.T, b Backend) {
+	ctx := context.Background()
+	uid := uuid.NewString()
+
+	_, err := b.Orders.GetById(ctx, uid)
+	assert.ErrorIs(t, err, domain.OrderNotFoundError)
+	assert.ErrorIs(t, err, domain.NotFoundError)
+
+	_, err = b.Deliveries.GetByOrderId(ctx, uid)
+	assert.ErrorIs(t, err, domain.NotFoundError)
+
+	_, err = b.Payments.GetByOrderId(ctx, uid)
+	assert.ErrorIs(t, err, domain.NotFoundError)
+
+	items, err := b.Items.GetByTrackNumber(ctx, uid)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func testDuplicateOrderIsConflict(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	err := b.Orders.Save(ctx, order)
+	assert.ErrorIs(t, err, domain.OrderAlreadyExistsError)
+	assert.ErrorIs(t, err, domain.ConflictError)
+}
+
+func testItemOfUnknownOrderIsConflict(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return b.Items.SaveAll(ctx, order.Items)
+	})
+	var itemErr *domain.ItemSaveError
+	require.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 0, itemErr.Index)
+	assert.Equal(t, order.Items[0].Rid, itemErr.Rid)
+	assert.ErrorIs(t, err, domain.ConflictError)
+}
+
+// testFailingItemInBatchIsReported: ошибка в середине пачки указывает на свой товар, а не на первый или последний
+func testFailingItemInBatchIsReported(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	items := order.Items
+	order.Items = nil
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	duplicate := items[0]
+	duplicate.ChrtID++
+	batch := []domain.Item{items[0], duplicate, items[1]}
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return b.Items.SaveAll(ctx, batch)
+	})
+	var itemErr *domain.ItemSaveError
+	require.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 1, itemErr.Index)
+	assert.Equal(t, duplicate.Rid, itemErr.Rid)
+	assert.ErrorIs(t, err, domain.ConflictError)
+
+	saved, err := b.Items.GetByTrackNumber(ctx, order.TrackNumber)
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+}
+
+func testRollbackDiscardsWrites(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.Orders.Save(ctx, order); err != nil {
+			return err
+		}
+		return rollbackError
+	})
+	assert.ErrorIs(t, err, rollbackError)
+
+	_, err = b.Orders.GetById(ctx, order.OrderUID)
+	assert.ErrorIs(t, err, domain.OrderNotFoundError)
+}
+
+func testSavepointRollbackKeepsOuterWrite(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.Orders.Save(ctx, order); err != nil {
+			return err
+		}
+		nestedErr := b.Tx.WithinTransactionOptions(ctx, protocols.TxOptions{Savepoint: true},
+			func(ctx context.Context) error {
+				if err := b.Deliveries.Save(ctx, &order.Delivery); err != nil {
+					return err
+				}
+				return rollbackError
+			})
+		assert.ErrorIs(t, nestedErr, rollbackError)
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = b.Orders.GetById(ctx, order.OrderUID)
+	assert.NoError(t, err)
+	_, err = b.Deliveries.GetByOrderId(ctx, order.OrderUID)
+	assert.ErrorIs(t, err, domain.NotFoundError)
+}
+
+func testReadOnlyTransactionRejectsWrites(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	readOnly := protocols.TxOptions{AccessMode: protocols.AccessReadOnly}
+
+	err := b.Tx.WithinTransactionOptions(ctx, readOnly, func(ctx context.Context) error {
+		return b.Orders.Save(ctx, order)
+	})
+	assert.Error(t, err)
+
+	err = b.Tx.WithinTransactionOptions(ctx, readOnly, func(ctx context.Context) error {
+		return b.Tx.WithinTransactionOptions(ctx, protocols.TxOptions{AccessMode: protocols.AccessReadWrite},
+			func(ctx context.Context) error { return nil })
+	})
+	assert.ErrorIs(t, err, persistent.IncompatibleTransactionError)
+
+	_, err = b.Orders.GetById(ctx, order.OrderUID)
+	assert.ErrorIs(t, err, domain.OrderNotFoundError)
+}
+
+func testOrderUIDsUpdatedAfter(t *testing.T, b Backend) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	older, newer := NewOrder(since.Add(-time.Minute)), NewOrder(since.Add(time.Minute))
+	// Заказы созданы до since, но один сменил статус, а другой изменён командой позже
+	paid, amended := NewOrder(since.Add(-time.Minute)), NewOrder(since.Add(-time.Minute))
+	for _, order := range []*domain.Order{older, newer, paid, amended} {
+		require.NoError(t, SaveOrder(ctx, b, order))
+	}
+	require.NoError(t, b.Statuses.UpdateStatus(ctx, paid.OrderUID, domain.StatusCreated, domain.StatusPaid))
+	require.NoError(t, b.Orders.UpdateVersion(ctx, amended.OrderUID, amended.Version))
+
+	uids, err := b.Orders.GetOrderUIDsUpdatedAfter(ctx, since)
+	require.NoError(t, err)
+	assert.Contains(t, uids, newer.OrderUID)
+	assert.Contains(t, uids, paid.OrderUID)
+	assert.Contains(t, uids, amended.OrderUID)
+	assert.NotContains(t, uids, older.OrderUID)
+
+	all, err := b.Orders.GetAllOrderUIDs(ctx)
+	require.NoError(t, err)
+	assert.Subset(t, all, []string{older.OrderUID, newer.OrderUID})
+}
+
+func testStatusChangeWithHistory(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+	createdAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+	paidAt := createdAt.Add(30 * time.Second)
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.Statuses.UpdateStatus(ctx, order.OrderUID, domain.StatusCreated, domain.StatusPaid); err != nil {
+			return err
+		}
+		// История возвращается по времени смены статуса, а не по порядку вставки
+		paid := domain.StatusChange{Status: domain.StatusPaid, ChangedAt: paidAt}
+		if err := b.Statuses.AddHistory(ctx, order.OrderUID, paid); err != nil {
+			return err
+		}
+		created := domain.StatusChange{Status: domain.StatusCreated, ChangedAt: createdAt}
+		return b.Statuses.AddHistory(ctx, order.OrderUID, created)
+	})
+	require.NoError(t, err)
+
+	stored, err := b.Orders.GetById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPaid, stored.Status)
+
+	history, err := b.Statuses.GetHistory(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, domain.StatusCreated, history[0].Status)
+	assert.True(t, createdAt.Equal(history[0].ChangedAt))
+	assert.Equal(t, domain.StatusPaid, history[1].Status)
+	assert.True(t, paidAt.Equal(history[1].ChangedAt))
+
+	history, err = b.Statuses.GetHistory(ctx, uuid.NewString())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func testStaleStatusChangeIsRetryable(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	err := b.Statuses.UpdateStatus(ctx, order.OrderUID, domain.StatusPaid, domain.StatusAssembling)
+	assert.ErrorIs(t, err, domain.RetryableError)
+	stored, err := b.Orders.GetById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusCreated, stored.Status)
+
+	err = b.Statuses.UpdateStatus(ctx, uuid.NewString(), domain.StatusCreated, domain.StatusPaid)
+	assert.ErrorIs(t, err, domain.OrderNotFoundError)
+
+	orphan := domain.StatusChange{Status: domain.StatusPaid, ChangedAt: time.Now()}
+	err = b.Statuses.AddHistory(ctx, uuid.NewString(), orphan)
+	assert.ErrorIs(t, err, domain.ConflictError)
+}
+
+func testVersionIsCompareAndSet(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	require.NoError(t, b.Orders.UpdateVersion(ctx, order.OrderUID, 1))
+	err := b.Orders.UpdateVersion(ctx, order.OrderUID, 1)
+	assert.ErrorIs(t, err, domain.RetryableError)
+	stored, err := b.Orders.GetById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Version)
+
+	err = b.Orders.UpdateVersion(ctx, uuid.NewString(), 1)
+	assert.ErrorIs(t, err, domain.NotFoundError)
+}
+
+func testUpdatesAreReadBack(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		delivery := order.Delivery
+		delivery.City, delivery.Address = "Haifa", "Herzl 1"
+		if err := b.Deliveries.Update(ctx, &delivery); err != nil {
+			return err
+		}
+		payment := order.Payment
+		payment.GoodsTotal, payment.Amount = 317, 1817
+		if err := b.Payments.Update(ctx, &payment); err != nil {
+			return err
+		}
+		return b.Items.Delete(ctx, order.TrackNumber, order.Items[1].Rid)
+	})
+	require.NoError(t, err)
+
+	delivery, err := b.Deliveries.GetByOrderId(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, "Haifa", delivery.City)
+	assert.Equal(t, "Herzl 1", delivery.Address)
+	assert.Equal(t, order.Delivery.Phone, delivery.Phone)
+
+	payment, err := b.Payments.GetByOrderId(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.MinorUnits(317), payment.GoodsTotal)
+	assert.Equal(t, domain.MinorUnits(1817), payment.Amount)
+
+	items, err := b.Items.GetByTrackNumber(ctx, order.TrackNumber)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Item{order.Items[0]}, items)
+}
+
+func testUpdateOfMissingRowIsNotFound(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+
+	assert.ErrorIs(t, b.Deliveries.Update(ctx, &order.Delivery), domain.NotFoundError)
+	assert.ErrorIs(t, b.Payments.Update(ctx, &order.Payment), domain.NotFoundError)
+	assert.ErrorIs(t, b.Items.Delete(ctx, order.TrackNumber, order.Items[0].Rid), domain.NotFoundError)
+}
+
+func testSecondPaymentOfOrderIsConflict(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+
+	second := order.Payment
+	second.Transaction = "pay-second"
+	assert.ErrorIs(t, b.Payments.Save(ctx, &second), domain.ConflictError)
+}
+
+func testPaymentOperations(t *testing.T, b Backend) {
+	ctx := context.Background()
+	order := NewOrder(time.Now())
+	require.NoError(t, SaveOrder(ctx, b, order))
+	chargedAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond).UTC()
+	operation := func(transaction string, opType domain.PaymentOperationType, status domain.PaymentOperationStatus,
+		amount domain.MinorUnits, performedAt time.Time) *domain.PaymentOperation {
+		return &domain.PaymentOperation{Transaction: transaction, Type: opType, Status: status, Amount: amount,
+			Currency: "USD", Provider: "wbpay", PerformedAt: performedAt}
+	}
+	// Операции возвращаются по времени проведения, а не по порядку вставки
+	refund := operation("refund-1", domain.PaymentRefund, domain.PaymentPending, 100, chargedAt.Add(time.Minute))
+	charge := operation("charge-1", domain.PaymentCharge, domain.PaymentSucceeded, 1917, chargedAt)
+
+	err := b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := b.Operations.Save(ctx, order.OrderUID, refund); err != nil {
+			return err
+		}
+		if err := b.Operations.Save(ctx, order.OrderUID, charge); err != nil {
+			return err
+		}
+		return b.Operations.UpdateStatus(ctx, order.OrderUID, refund.Transaction, domain.PaymentSucceeded)
+	})
+	require.NoError(t, err)
+
+	operations, err := b.Operations.GetByOrderId(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Len(t, operations, 2)
+	for i := range operations {
+		operations[i].PerformedAt = operations[i].PerformedAt.UTC()
+	}
+	refund.Status = domain.PaymentSucceeded
+	assert.Equal(t, []domain.PaymentOperation{*charge, *refund}, operations)
+
+	assert.ErrorIs(t, b.Operations.Save(ctx, order.OrderUID, charge), domain.ConflictError)
+	assert.ErrorIs(t, b.Operations.UpdateStatus(ctx, order.OrderUID, "missing", domain.PaymentFailed),
+		domain.NotFoundError)
+	err = b.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return b.Operations.Save(ctx, uuid.NewString(), charge)
+	})
+	assert.ErrorIs(t, err, domain.ConflictError)
+
+	operations, err = b.Operations.GetByOrderId(ctx, uuid.NewString())
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+}
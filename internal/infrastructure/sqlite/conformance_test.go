@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"web_service/internal/infrastructure/repotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	for name, dsn := range map[string]func(t *testing.T) string{
+		"memory": func(t *testing.T) string { return "sqlite::memory:" },
+		"file":   func(t *testing.T) string { return "sqlite:" + filepath.Join(t.TempDir(), "orders.db") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			repotest.Run(t, func(t *testing.T) repotest.Backend {
+				db, err := Open(context.Background(), dsn(t))
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = db.Close() })
+				return repotest.Backend{
+					Orders:     NewOrderRepo(db),
+					Deliveries: NewDeliveryRepo(db),
+					Payments:   NewPaymentRepo(db),
+					Operations: NewPaymentOperationRepo(db),
+					Items:      NewItemRepo(db),
+					Statuses:   NewOrderStatusRepo(db),
+					Tx:         NewTransactionManager(db),
+				}
+			})
+		})
+	}
+}
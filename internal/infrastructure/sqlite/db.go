@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Scheme — префикс DSN встроенной БД: sqlite:orders.db, sqlite:///var/lib/orders.db или sqlite::memory:
+const Scheme = "sqlite:"
+
+const memoryPath = ":memory:"
+
+//go:embed schema.sql
+var schema string
+
+// IsDSN сообщает, указывает ли dsn на встроенную БД
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme)
+}
+
+// Open открывает файл БД (или БД в памяти) и создаёт таблицы, если их ещё нет. Параметры после "?"
+// передаются драйверу modernc.org/sqlite как есть.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(dsn, Scheme), "//"), "?")
+	if path == "" {
+		return nil, fmt.Errorf("sqlite dsn %q has no database path", dsn)
+	}
+
+	// Транзакции на запись сразу берут блокировку записи (BEGIN IMMEDIATE): иначе две транзакции,
+	// начавшие с чтения, получают SQLITE_BUSY при попытке записать, не дождавшись busy_timeout.
+	// Драйвер применяет _txlock только к транзакциям без ReadOnly: read-only транзакции начинаются
+	// с обычного BEGIN и в режиме WAL читают параллельно с пишущей транзакцией.
+	params := []string{
+		"_pragma=busy_timeout(5000)",
+		"_pragma=foreign_keys(1)",
+		"_txlock=immediate",
+	}
+	if path != memoryPath {
+		params = append(params, "_pragma=journal_mode(WAL)")
+	}
+	if query != "" {
+		params = append(params, query)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+strings.Join(params, "&"))
+	if err != nil {
+		return nil, err
+	}
+	if path == memoryPath {
+		// У каждого соединения своя БД в памяти, поэтому держим ровно одно и не закрываем его
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxIdleTime(0)
+		db.SetConnMaxLifetime(0)
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create sqlite schema: %w", err)
+	}
+	for _, column := range addedColumns {
+		if err := addColumnIfMissing(ctx, db, column.table, column.name, column.definition); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("migrate sqlite schema: %w", err)
+		}
+	}
+	if err := rekeyPayments(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate sqlite schema: %w", err)
+	}
+	return db, nil
+}
+
+// addedColumns — столбцы, появившиеся в schema.sql позже самих таблиц: CREATE TABLE IF NOT EXISTS
+// не добавит их в файл БД, созданный прежней версией
+var addedColumns = []struct{ table, name, definition string }{
+	{table: "orders", name: "status", definition: "TEXT NOT NULL DEFAULT 'created'"},
+	{table: "orders", name: "version", definition: "INTEGER NOT NULL DEFAULT 1"},
+	{table: "orders", name: "updated_at", definition: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// rekeyPaymentsStatements пересоздают payments с ключом order_uid вместо ссылки transaction на заказ
+// и переносят прежние оплаты с payment_dt в payment_operations как успешные списания
+var rekeyPaymentsStatements = []string{
+	`CREATE TABLE payments_rekeyed (
+        id            TEXT PRIMARY KEY,
+        order_uid     TEXT    NOT NULL REFERENCES orders (order_uid) ON DELETE CASCADE,
+        "transaction" TEXT    NOT NULL,
+        request_id    TEXT,
+        currency      TEXT    NOT NULL,
+        provider      TEXT    NOT NULL,
+        amount        INTEGER NOT NULL,
+        payment_dt    INTEGER NOT NULL,
+        bank          TEXT    NOT NULL,
+        delivery_cost INTEGER NOT NULL,
+        goods_total   INTEGER NOT NULL,
+        custom_fee    INTEGER NOT NULL
+    )`,
+	`INSERT INTO payments_rekeyed
+        SELECT id, "transaction", "transaction", request_id, currency, provider, amount, payment_dt, bank,
+            delivery_cost, goods_total, custom_fee
+        FROM payments`,
+	`DROP TABLE payments`,
+	`ALTER TABLE payments_rekeyed RENAME TO payments`,
+	`INSERT OR IGNORE INTO payment_operations
+        (order_uid, "transaction", type, status, amount, currency, provider, performed_at)
+        SELECT order_uid, "transaction", 'charge', 'succeeded', amount, currency, provider, payment_dt * 1000000
+        FROM payments WHERE payment_dt > 0`,
+}
+
+// rekeyPayments переводит файл БД прежней версии, где оплата ссылалась на заказ через transaction.
+// SQLite не умеет удалять внешний ключ, поэтому таблица пересоздаётся.
+func rekeyPayments(ctx context.Context, db *sql.DB) error {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_info('payments') WHERE name = 'order_uid'`).
+		Scan(&count)
+	if err == nil && count == 0 {
+		err = execInTx(ctx, db, rekeyPaymentsStatements)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_order_uid ON payments (order_uid)`)
+	return err
+}
+
+func execInTx(ctx context.Context, db *sql.DB, statements []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func addColumnIfMissing(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).
+		Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
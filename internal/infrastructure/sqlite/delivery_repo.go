@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"web_service/internal/domain"
+)
+
+type DeliveryRepo struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepo(db *sql.DB) *DeliveryRepo {
+	return &DeliveryRepo{db: db}
+}
+
+func (r *DeliveryRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Delivery, error) {
+	row := querier(ctx, r.db).QueryRowContext(ctx,
+		`SELECT order_uid, name, phone, zip, city, address, region, email
+         FROM deliveries
+         WHERE order_uid = ?`,
+		orderUid,
+	)
+
+	var delivery domain.Delivery
+	err := row.Scan(
+		&delivery.OrderUID,
+		&delivery.Name,
+		&delivery.Phone,
+		&delivery.Zip,
+		&delivery.City,
+		&delivery.Address,
+		&delivery.Region,
+		&delivery.Email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get delivery of order %s: %w", orderUid, TranslateError(err))
+	}
+
+	return &delivery, nil
+}
+
+func (r *DeliveryRepo) Save(ctx context.Context, delivery *domain.Delivery) error {
+	_, err := querier(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.OrderUID,
+		delivery.Name,
+		delivery.Phone,
+		delivery.Zip,
+		delivery.City,
+		delivery.Address,
+		delivery.Region,
+		delivery.Email,
+	)
+	if err != nil {
+		log.Printf("Save delivery error: %v", err)
+		return fmt.Errorf("failed to save delivery: %w", TranslateError(err))
+	}
+
+	return nil
+}
+
+func (r *DeliveryRepo) Update(ctx context.Context, delivery *domain.Delivery) error {
+	result, err := querier(ctx, r.db).ExecContext(ctx,
+		`UPDATE deliveries SET name = ?, phone = ?, zip = ?, city = ?, address = ?, region = ?, email = ?
+         WHERE order_uid = ?`,
+		delivery.Name,
+		delivery.Phone,
+		delivery.Zip,
+		delivery.City,
+		delivery.Address,
+		delivery.Region,
+		delivery.Email,
+		delivery.OrderUID,
+	)
+	if err != nil {
+		return fmt.Errorf("update delivery of order %s: %w", delivery.OrderUID, TranslateError(err))
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return fmt.Errorf("update delivery of order %s: %w", delivery.OrderUID, domain.NotFoundError)
+	}
+
+	return nil
+}
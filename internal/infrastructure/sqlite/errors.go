@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"web_service/internal/domain"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// TranslateError переводит ошибки SQLite в те же доменные категории, что и persistent.TranslateError для PostgreSQL:
+// отсутствие строки — domain.NotFoundError, нарушение ограничений — domain.ConflictError,
+// занятая другой транзакцией БД — domain.RetryableError
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", domain.NotFoundError, err)
+	}
+	switch errorCode(err) {
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %w", domain.ConflictError, err)
+	}
+	if isRetryable(err) {
+		return fmt.Errorf("%w: %w", domain.RetryableError, err)
+	}
+	return err
+}
+
+// isRetryable узнаёт SQLITE_BUSY и SQLITE_LOCKED вместе с их расширенными кодами
+func isRetryable(err error) bool {
+	if errors.Is(err, domain.RetryableError) {
+		return true
+	}
+	switch errorCode(err) & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
+
+func errorCode(err error) int {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"web_service/internal/domain"
+)
+
+type ItemRepo struct {
+	db *sql.DB
+}
+
+func NewItemRepo(db *sql.DB) *ItemRepo {
+	return &ItemRepo{db: db}
+}
+
+func (r *ItemRepo) GetByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Item, error) {
+	rows, err := querier(ctx, r.db).QueryContext(ctx,
+		`SELECT
+            	rid, track_number, chrt_id, price, name, sale, size,
+    			total_price, nm_id, brand, status
+			 FROM items
+			 WHERE track_number = ?`,
+		trackNumber,
+	)
+	if err != nil {
+		log.Printf("get items database error: %v", err)
+		return nil, fmt.Errorf("failed to query items: %w", TranslateError(err))
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []domain.Item
+	for rows.Next() {
+		var item domain.Item
+		err := rows.Scan(
+			&item.Rid,
+			&item.TrackNumber,
+			&item.ChrtID,
+			&item.Price,
+			&item.Name,
+			&item.Sale,
+			&item.Size,
+			&item.TotalPrice,
+			&item.NmID,
+			&item.Brand,
+			&item.Status,
+		)
+		if err != nil {
+			log.Printf("failed to scan item: %v", err)
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("items Rows iteration error: %v", err)
+		return nil, fmt.Errorf("error iterating items rows: %w", TranslateError(err))
+	}
+
+	return items, nil
+}
+
+const insertItemSQL = `INSERT INTO items (
+            rid, track_number, chrt_id, price, name, sale, size,
+            total_price, nm_id, brand, status
+         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func (r *ItemRepo) Save(ctx context.Context, item *domain.Item) error {
+	_, err := querier(ctx, r.db).ExecContext(ctx, insertItemSQL, itemArgs(item)...)
+	if err != nil {
+		log.Printf("failed to save item: %v", err)
+		return fmt.Errorf("failed to save item: %w", TranslateError(err))
+	}
+
+	return nil
+}
+
+// SaveAll вставляет товары по одному: встроенной БД не нужен round-trip, который экономит pgx.Batch.
+// Как и в PostgreSQL, первая ошибка останавливает вставку и возвращается как *domain.ItemSaveError.
+func (r *ItemRepo) SaveAll(ctx context.Context, items []domain.Item) error {
+	q := querier(ctx, r.db)
+	for i := range items {
+		if _, err := q.ExecContext(ctx, insertItemSQL, itemArgs(&items[i])...); err != nil {
+			log.Printf("failed to save item %s: %v", items[i].Rid, err)
+			return fmt.Errorf("failed to save items: %w", &domain.ItemSaveError{Index: i, Rid: items[i].Rid,
+				Err: TranslateError(err)})
+		}
+	}
+	return nil
+}
+
+func (r *ItemRepo) Delete(ctx context.Context, trackNumber, rid string) error {
+	result, err := querier(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM items WHERE track_number = ? AND rid = ?`, trackNumber, rid)
+	if err != nil {
+		return fmt.Errorf("delete item %s: %w", rid, TranslateError(err))
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return fmt.Errorf("delete item %s: %w: no such item in order with track number %s",
+			rid, domain.NotFoundError, trackNumber)
+	}
+
+	return nil
+}
+
+func itemArgs(item *domain.Item) []any {
+	return []any{
+		item.Rid,
+		item.TrackNumber,
+		item.ChrtID,
+		item.Price,
+		item.Name,
+		item.Sale,
+		item.Size,
+		item.TotalPrice,
+		item.NmID,
+		item.Brand,
+		item.Status,
+	}
+}
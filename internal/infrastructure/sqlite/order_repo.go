@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"web_service/internal/domain"
+)
+
+type OrderRepo struct {
+	db *sql.DB
+}
+
+func NewOrderRepo(db *sql.DB) *OrderRepo {
+	return &OrderRepo{db: db}
+}
+
+func (r *OrderRepo) GetById(ctx context.Context, orderUid string) (*domain.Order, error) {
+	row := querier(ctx, r.db).QueryRowContext(ctx,
+		`SELECT order_uid, track_number, entry, locale, internal_signature,
+				customer_id, delivery_service, shardkey, sm_id, date_created,
+				oof_shard, status, version, updated_at FROM orders WHERE order_uid = ?`,
+		orderUid)
+	var order domain.Order
+	var internalSignature sql.NullString
+	var dateCreated, updatedAt int64
+	err := row.Scan(&order.OrderUID, &order.TrackNumber, &order.Entry,
+		&order.Locale, &internalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.Shardkey,
+		&order.SmID, &dateCreated, &order.OofShard, &order.Status, &order.Version, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+		}
+		return nil, fmt.Errorf("get order %s: %w", orderUid, TranslateError(err))
+	}
+	order.InternalSignature = internalSignature.String
+	order.DateCreated = time.UnixMicro(dateCreated)
+	order.UpdatedAt = time.UnixMicro(updatedAt)
+
+	return &order, nil
+}
+
+// Save сообщает о дубликате через ON CONFLICT: SQLite проверяет первичный ключ после остальных
+// уникальных индексов, и по самой ошибке повтор заказа не отличить от чужого track_number
+func (r *OrderRepo) Save(ctx context.Context, order *domain.Order) error {
+	result, err := querier(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO orders (
+            order_uid, track_number, entry, locale, internal_signature,
+            customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, status, version, updated_at
+         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+         ON CONFLICT (order_uid) DO NOTHING`,
+		order.OrderUID, order.TrackNumber, order.Entry,
+		order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.Shardkey,
+		order.SmID, order.DateCreated.UnixMicro(), order.OofShard, order.Status, order.Version,
+		order.UpdatedAt.UnixMicro(),
+	)
+	if err != nil {
+		return fmt.Errorf("save order: %w", TranslateError(err))
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+		return fmt.Errorf("%w: database already has order with uid %s", domain.OrderAlreadyExistsError,
+			order.OrderUID)
+	}
+
+	return nil
+}
+
+func (r *OrderRepo) UpdateVersion(ctx context.Context, orderUid string, version int) error {
+	q := querier(ctx, r.db)
+	result, err := q.ExecContext(ctx,
+		`UPDATE orders SET version = version + 1, updated_at = ? WHERE order_uid = ? AND version = ?`,
+		time.Now().UnixMicro(), orderUid, version)
+	if err != nil {
+		return fmt.Errorf("update version of order %s: %w", orderUid, TranslateError(err))
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		return nil
+	}
+
+	var current int
+	err = q.QueryRowContext(ctx, `SELECT version FROM orders WHERE order_uid = ?`, orderUid).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+	}
+	if err != nil {
+		return fmt.Errorf("update version of order %s: %w", orderUid, TranslateError(err))
+	}
+	return fmt.Errorf("%w: order %s changed concurrently, version %d instead of %d",
+		domain.RetryableError, orderUid, current, version)
+}
+
+func (r *OrderRepo) GetAllOrderUIDs(ctx context.Context) ([]string, error) {
+	rows, err := querier(ctx, r.db).QueryContext(ctx, `SELECT order_uid FROM orders`)
+	if err != nil {
+		return nil, fmt.Errorf("could not load all order uids, database error: %w", TranslateError(err))
+	}
+	return scanOrderUIDs(rows)
+}
+
+func (r *OrderRepo) GetOrderUIDsUpdatedAfter(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := querier(ctx, r.db).QueryContext(ctx,
+		`SELECT order_uid FROM orders WHERE updated_at > ?`, since.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("could not load order uids updated after %s, database error: %w", since,
+			TranslateError(err))
+	}
+	return scanOrderUIDs(rows)
+}
+
+func scanOrderUIDs(rows *sql.Rows) ([]string, error) {
+	defer func() { _ = rows.Close() }()
+	result := make([]string, 0)
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return nil, fmt.Errorf("could not scan order uid row: %w", err)
+		}
+		result = append(result, orderUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order uid rows: %w", TranslateError(err))
+	}
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"web_service/internal/domain"
+)
+
+type OrderStatusRepo struct {
+	db *sql.DB
+}
+
+func NewOrderStatusRepo(db *sql.DB) *OrderStatusRepo {
+	return &OrderStatusRepo{db: db}
+}
+
+func (r *OrderStatusRepo) UpdateStatus(ctx context.Context, orderUid string, from, to domain.OrderStatus) error {
+	q := querier(ctx, r.db)
+	result, err := q.ExecContext(ctx,
+		`UPDATE orders SET status = ?, updated_at = ? WHERE order_uid = ? AND status = ?`,
+		to, time.Now().UnixMicro(), orderUid, from)
+	if err != nil {
+		return fmt.Errorf("update status of order %s: %w", orderUid, TranslateError(err))
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		return nil
+	}
+
+	var current domain.OrderStatus
+	err = q.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_uid = ?`, orderUid).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: database has no order with uid %s", domain.OrderNotFoundError, orderUid)
+	}
+	if err != nil {
+		return fmt.Errorf("update status of order %s: %w", orderUid, TranslateError(err))
+	}
+	return fmt.Errorf("%w: status of order %s changed concurrently from %s to %s",
+		domain.RetryableError, orderUid, from, current)
+}
+
+func (r *OrderStatusRepo) AddHistory(ctx context.Context, orderUid string, change domain.StatusChange) error {
+	_, err := querier(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO order_status_history (order_uid, status, changed_at) VALUES (?, ?, ?)`,
+		orderUid, change.Status, change.ChangedAt.UnixMicro())
+	if err != nil {
+		return fmt.Errorf("add status history of order %s: %w", orderUid, TranslateError(err))
+	}
+	return nil
+}
+
+func (r *OrderStatusRepo) GetHistory(ctx context.Context, orderUid string) ([]domain.StatusChange, error) {
+	rows, err := querier(ctx, r.db).QueryContext(ctx,
+		`SELECT status, changed_at FROM order_status_history WHERE order_uid = ? ORDER BY changed_at, id`,
+		orderUid)
+	if err != nil {
+		return nil, fmt.Errorf("get status history of order %s: %w", orderUid, TranslateError(err))
+	}
+	defer func() { _ = rows.Close() }()
+
+	history := make([]domain.StatusChange, 0)
+	for rows.Next() {
+		var change domain.StatusChange
+		var changedAt int64
+		if err := rows.Scan(&change.Status, &changedAt); err != nil {
+			return nil, fmt.Errorf("scan status history of order %s: %w", orderUid, err)
+		}
+		change.ChangedAt = time.UnixMicro(changedAt)
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get status history of order %s: %w", orderUid, TranslateError(err))
+	}
+	return history, nil
+}
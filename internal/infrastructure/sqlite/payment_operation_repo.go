@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"web_service/internal/domain"
+)
+
+type PaymentOperationRepo struct {
+	db *sql.DB
+}
+
+func NewPaymentOperationRepo(db *sql.DB) *PaymentOperationRepo {
+	return &PaymentOperationRepo{db: db}
+}
+
+func (r *PaymentOperationRepo) GetByOrderId(ctx context.Context, orderUid string) ([]domain.PaymentOperation, error) {
+	rows, err := querier(ctx, r.db).QueryContext(ctx,
+		`SELECT "transaction", type, status, amount, currency, provider, performed_at
+         FROM payment_operations WHERE order_uid = ? ORDER BY performed_at, id`,
+		orderUid)
+	if err != nil {
+		return nil, fmt.Errorf("get payment operations of order %s: %w", orderUid, TranslateError(err))
+	}
+	defer func() { _ = rows.Close() }()
+
+	operations := make([]domain.PaymentOperation, 0)
+	for rows.Next() {
+		var op domain.PaymentOperation
+		var performedAt int64
+		if err := rows.Scan(&op.Transaction, &op.Type, &op.Status, &op.Amount, &op.Currency, &op.Provider,
+			&performedAt); err != nil {
+			return nil, fmt.Errorf("scan payment operations of order %s: %w", orderUid, err)
+		}
+		op.PerformedAt = time.UnixMicro(performedAt)
+		operations = append(operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get payment operations of order %s: %w", orderUid, TranslateError(err))
+	}
+	return operations, nil
+}
+
+func (r *PaymentOperationRepo) Save(ctx context.Context, orderUid string, operation *domain.PaymentOperation) error {
+	_, err := querier(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO payment_operations
+            (order_uid, "transaction", type, status, amount, currency, provider, performed_at)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		orderUid, operation.Transaction, operation.Type, operation.Status, operation.Amount,
+		operation.Currency, operation.Provider, operation.PerformedAt.UnixMicro())
+	if err != nil {
+		return fmt.Errorf("save payment operation %s of order %s: %w", operation.Transaction, orderUid,
+			TranslateError(err))
+	}
+	return nil
+}
+
+func (r *PaymentOperationRepo) UpdateStatus(ctx context.Context, orderUid, transaction string,
+	status domain.PaymentOperationStatus) error {
+	result, err := querier(ctx, r.db).ExecContext(ctx,
+		`UPDATE payment_operations SET status = ? WHERE order_uid = ? AND "transaction" = ?`,
+		status, orderUid, transaction)
+	if err != nil {
+		return fmt.Errorf("update payment operation %s of order %s: %w", transaction, orderUid,
+			TranslateError(err))
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return fmt.Errorf("update payment operation %s of order %s: %w", transaction, orderUid,
+			domain.NotFoundError)
+	}
+	return nil
+}
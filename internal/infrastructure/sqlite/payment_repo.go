@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"web_service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepo struct {
+	db *sql.DB
+}
+
+func NewPaymentRepo(db *sql.DB) *PaymentRepo {
+	return &PaymentRepo{db: db}
+}
+
+func (r *PaymentRepo) GetByOrderId(ctx context.Context, orderUid string) (*domain.Payment, error) {
+	row := querier(ctx, r.db).QueryRowContext(ctx,
+		"select id, order_uid, \"transaction\", request_id, currency, provider, amount, payment_dt, bank,"+
+			"delivery_cost, goods_total, custom_fee"+
+			" from payments where order_uid = ?",
+		orderUid)
+	var payment domain.Payment
+	err := row.Scan(&payment.ID, &payment.OrderUID, &payment.Transaction, &payment.RequestID,
+		&payment.Currency, &payment.Provider,
+		&payment.Amount, &payment.PaymentDt, &payment.Bank, &payment.DeliveryCost,
+		&payment.GoodsTotal, &payment.CustomFee)
+	if err != nil {
+		return nil, fmt.Errorf("get payment of order %s: %w", orderUid, TranslateError(err))
+	}
+	return &payment, nil
+}
+
+// Save генерирует id оплаты сам: в отличие от PostgreSQL, у SQLite нет gen_random_uuid()
+func (r *PaymentRepo) Save(ctx context.Context, payment *domain.Payment) error {
+	_, err := querier(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO payments (
+            id, order_uid, "transaction", request_id, currency, provider, amount, payment_dt, bank,
+            delivery_cost, goods_total, custom_fee
+         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		uuid.NewString(),
+		payment.OrderUID,
+		payment.Transaction,
+		payment.RequestID,
+		payment.Currency,
+		payment.Provider,
+		payment.Amount,
+		payment.PaymentDt,
+		payment.Bank,
+		payment.DeliveryCost,
+		payment.GoodsTotal,
+		payment.CustomFee,
+	)
+	if err != nil {
+		log.Printf("Save payment error: %v\n", err)
+		return fmt.Errorf("failed to save payment: %w", TranslateError(err))
+	}
+
+	return nil
+}
+
+func (r *PaymentRepo) Update(ctx context.Context, payment *domain.Payment) error {
+	result, err := querier(ctx, r.db).ExecContext(ctx,
+		`UPDATE payments SET "transaction" = ?, request_id = ?, currency = ?, provider = ?, amount = ?,
+            payment_dt = ?, bank = ?, delivery_cost = ?, goods_total = ?, custom_fee = ?
+         WHERE order_uid = ?`,
+		payment.Transaction,
+		payment.RequestID,
+		payment.Currency,
+		payment.Provider,
+		payment.Amount,
+		payment.PaymentDt,
+		payment.Bank,
+		payment.DeliveryCost,
+		payment.GoodsTotal,
+		payment.CustomFee,
+		payment.OrderUID,
+	)
+	if err != nil {
+		return fmt.Errorf("update payment of order %s: %w", payment.OrderUID, TranslateError(err))
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return fmt.Errorf("update payment of order %s: %w", payment.OrderUID, domain.NotFoundError)
+	}
+
+	return nil
+}
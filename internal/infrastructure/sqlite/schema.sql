@@ -0,0 +1,85 @@
+-- Схема повторяет deployments/init.sql в типах SQLite; date_created, updated_at, changed_at и performed_at
+-- хранятся в микросекундах Unix, чтобы сравнение по времени работало так же, как для timestamptz.
+-- transaction — ключевое слово SQLite, поэтому этот столбец везде берётся в кавычки
+CREATE TABLE IF NOT EXISTS orders (
+    order_uid          TEXT PRIMARY KEY,
+    track_number       TEXT    NOT NULL UNIQUE,
+    entry              TEXT    NOT NULL,
+    locale             TEXT    NOT NULL,
+    internal_signature TEXT,
+    customer_id        TEXT    NOT NULL,
+    delivery_service   TEXT    NOT NULL,
+    shardkey           TEXT    NOT NULL,
+    sm_id              INTEGER NOT NULL,
+    date_created       INTEGER NOT NULL,
+    oof_shard          TEXT    NOT NULL,
+    status             TEXT    NOT NULL DEFAULT 'created',
+    version            INTEGER NOT NULL DEFAULT 1,
+    updated_at         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+    id        INTEGER PRIMARY KEY,
+    order_uid TEXT NOT NULL REFERENCES orders (order_uid) ON DELETE CASCADE,
+    name      TEXT NOT NULL,
+    phone     TEXT NOT NULL,
+    zip       TEXT NOT NULL,
+    city      TEXT NOT NULL,
+    address   TEXT NOT NULL,
+    region    TEXT NOT NULL,
+    email     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id            TEXT PRIMARY KEY,
+    order_uid     TEXT    NOT NULL REFERENCES orders (order_uid) ON DELETE CASCADE,
+    "transaction" TEXT    NOT NULL,
+    request_id    TEXT,
+    currency      TEXT    NOT NULL,
+    provider      TEXT    NOT NULL,
+    amount        INTEGER NOT NULL,
+    payment_dt    INTEGER NOT NULL,
+    bank          TEXT    NOT NULL,
+    delivery_cost INTEGER NOT NULL,
+    goods_total   INTEGER NOT NULL,
+    custom_fee    INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment_operations (
+    id            INTEGER PRIMARY KEY,
+    order_uid     TEXT    NOT NULL REFERENCES orders (order_uid) ON DELETE CASCADE,
+    "transaction" TEXT    NOT NULL,
+    type          TEXT    NOT NULL,
+    status        TEXT    NOT NULL,
+    amount        INTEGER NOT NULL,
+    currency      TEXT    NOT NULL,
+    provider      TEXT    NOT NULL,
+    performed_at  INTEGER NOT NULL,
+    UNIQUE (order_uid, "transaction")
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    rid          TEXT PRIMARY KEY,
+    track_number TEXT    NOT NULL REFERENCES orders (track_number),
+    chrt_id      INTEGER NOT NULL,
+    price        INTEGER NOT NULL,
+    name         TEXT    NOT NULL,
+    sale         INTEGER NOT NULL,
+    size         TEXT    NOT NULL,
+    total_price  INTEGER NOT NULL,
+    nm_id        INTEGER NOT NULL,
+    brand        TEXT    NOT NULL,
+    status       INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id         INTEGER PRIMARY KEY,
+    order_uid  TEXT    NOT NULL REFERENCES orders (order_uid) ON DELETE CASCADE,
+    status     TEXT    NOT NULL,
+    changed_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_order_uid ON deliveries (order_uid);
+-- Уникальный индекс payments (order_uid) создаёт миграция в db.go: в файле прежней версии столбца ещё нет
+CREATE INDEX IF NOT EXISTS idx_items_track_number ON items (track_number);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_uid ON order_status_history (order_uid, changed_at);
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+	"web_service/internal/infrastructure/persistent"
+	"web_service/internal/protocols"
+)
+
+// TransactionManager — менеджер транзакций встроенной БД. Транзакции SQLite всегда сериализуемы,
+// поэтому уровень изоляции из TxOptions не меняет поведения, а read only обеспечивается PRAGMA query_only.
+type TransactionManager struct {
+	db *sql.DB
+	// savepoints нумерует точки сохранения, чтобы у вложенных savepoint были разные имена
+	savepoints atomic.Uint64
+}
+
+func NewTransactionManager(db *sql.DB) *TransactionManager {
+	return &TransactionManager{db: db}
+}
+
+func (tm *TransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return tm.WithinTransactionOptions(ctx, protocols.TxOptions{}, fn)
+}
+
+func (tm *TransactionManager) WithinTransactionOptions(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if outer, ok := QuerierFromContext(ctx).(*sql.Tx); ok {
+		return tm.withinOuterTransaction(ctx, outer, opts, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := tm.run(ctx, opts, fn)
+		if err == nil || attempt >= opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+		delay := persistent.RetryDelay(opts.RetryBackoff, attempt)
+		log.Printf("Transaction failed with retryable error, retry %d/%d in %s: %v\n",
+			attempt+1, opts.MaxRetries, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (tm *TransactionManager) run(ctx context.Context, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	// Отдельное соединение нужно, чтобы снять query_only после read-only транзакции до возврата его в пул
+	conn, err := tm.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", TranslateError(err))
+	}
+	defer func() { _ = conn.Close() }()
+
+	readOnly := opts.AccessMode == protocols.AccessReadOnly
+	// ReadOnly обязателен для read-only транзакций: с ним драйвер не берёт блокировку записи (см. Open)
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", TranslateError(err))
+	}
+	if readOnly {
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), "PRAGMA query_only = 0"); err != nil {
+				log.Printf("Failed to reset query_only: %v\n", err)
+			}
+		}()
+		if _, err := tx.ExecContext(ctx, "PRAGMA query_only = 1"); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("begin read only transaction: %w", TranslateError(err))
+		}
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back transaction: %v\n", err)
+		}
+	}()
+
+	// Устанавливаем tx в контекст как Querier вместе с его настройками для вложенных вызовов
+	ctx = contextWithTxOptions(ContextWithQuerier(ctx, tx), opts)
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", TranslateError(err))
+	}
+	return nil
+}
+
+// withinOuterTransaction выполняет вложенный вызов в уже открытой транзакции, по желанию — в savepoint
+func (tm *TransactionManager) withinOuterTransaction(ctx context.Context, outer *sql.Tx, opts protocols.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if err := persistent.CheckNestedOptions(txOptionsFromContext(ctx), opts); err != nil {
+		return err
+	}
+	if !opts.Savepoint {
+		return fn(ctx)
+	}
+
+	name := fmt.Sprintf("sp_%d", tm.savepoints.Add(1))
+	if _, err := outer.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("create savepoint: %w", TranslateError(err))
+	}
+	if err := fn(ctx); err != nil {
+		// ROLLBACK TO оставляет savepoint открытым, поэтому его нужно ещё и освободить
+		if _, rollbackErr := outer.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO "+name+"; RELEASE "+name); rollbackErr != nil {
+			log.Printf("Failed to roll back to savepoint: %v\n", rollbackErr)
+		}
+		return err
+	}
+	if _, err := outer.ExecContext(ctx, "RELEASE "+name); err != nil {
+		return fmt.Errorf("release savepoint: %w", TranslateError(err))
+	}
+	return nil
+}
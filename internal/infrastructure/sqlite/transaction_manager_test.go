@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"web_service/internal/protocols"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyTransactionDoesNotWaitForWriter(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, "sqlite:"+filepath.Join(t.TempDir(), "orders.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	tm := NewTransactionManager(db)
+
+	writing := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			close(writing)
+			<-done
+			return nil
+		})
+	}()
+	<-writing
+	defer close(done)
+
+	// Пишущая транзакция держит блокировку записи, но чтение не должно ждать busy_timeout
+	readCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	err = tm.WithinTransactionOptions(readCtx, protocols.TxOptions{AccessMode: protocols.AccessReadOnly},
+		func(ctx context.Context) error {
+			var count int
+			return QuerierFromContext(ctx).QueryRowContext(ctx, `SELECT count(*) FROM orders`).Scan(&count)
+		})
+	require.NoError(t, err)
+}
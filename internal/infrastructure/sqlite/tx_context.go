@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"web_service/internal/protocols"
+)
+
+type querierKey struct{}
+
+type txOptionsKey struct{}
+
+// Querier — интерфейс, общий для *sql.DB и *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func ContextWithQuerier(ctx context.Context, q Querier) context.Context {
+	return context.WithValue(ctx, querierKey{}, q)
+}
+
+func QuerierFromContext(ctx context.Context) Querier {
+	if q, ok := ctx.Value(querierKey{}).(Querier); ok {
+		return q
+	}
+	return nil
+}
+
+func contextWithTxOptions(ctx context.Context, opts protocols.TxOptions) context.Context {
+	return context.WithValue(ctx, txOptionsKey{}, opts)
+}
+
+func txOptionsFromContext(ctx context.Context) protocols.TxOptions {
+	opts, _ := ctx.Value(txOptionsKey{}).(protocols.TxOptions)
+	return opts
+}
+
+// querier возвращает транзакцию из контекста или саму БД
+func querier(ctx context.Context, db *sql.DB) Querier {
+	if q := QuerierFromContext(ctx); q != nil {
+		return q
+	}
+	return db
+}
@@ -0,0 +1,11 @@
+package protocols
+
+import (
+	"context"
+	"web_service/internal/domain"
+)
+
+type AuthenticatorInterface interface {
+	// Authenticate проверяет учётные данные и возвращает domain.AuthenticationFailedError, если они не подходят
+	Authenticate(ctx context.Context, credential string) (*domain.Principal, error)
+}
@@ -0,0 +1,21 @@
+package protocols
+
+import "time"
+
+// CacheStats — состояние кеша заказов. Bytes — приблизительный объём заказов и готовых ответов в памяти.
+type CacheStats struct {
+	Entries     int
+	Bytes       int64
+	Hits        uint64
+	Misses      uint64
+	OldestEntry time.Time
+}
+
+// CacheAdminInterface реализуют кеши в памяти процесса, которыми можно управлять через admin API
+type CacheAdminInterface interface {
+	Stats() CacheStats
+	// Keys возвращает до limit ключей по возрастанию, начиная со следующего после after
+	Keys(after string, limit int) []string
+	// Flush удаляет все заказы из кеша
+	Flush()
+}
@@ -0,0 +1,11 @@
+package protocols
+
+import "context"
+
+// CacheInvalidationBusInterface рассылает другим репликам идентификаторы заказов,
+// которые нужно убрать из локального кеша
+type CacheInvalidationBusInterface interface {
+	Publish(orderUID string)
+	// Subscribe вызывает handler для каждого чужого сообщения, пока не отменён ctx
+	Subscribe(ctx context.Context, handler func(orderUID string)) error
+}
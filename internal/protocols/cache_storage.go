@@ -5,4 +5,14 @@ import "web_service/internal/domain"
 type OrderStorageInterface interface {
 	Get(orderUID string) (*domain.Order, error)
 	Save(orderUID string, order *domain.Order)
+	// SaveAll сохраняет пачку заказов; используется при прогреве кеша
+	SaveAll(orders []*domain.Order)
+	Delete(orderUID string)
+}
+
+// IterableOrderStorageInterface реализуют кеши в памяти процесса, содержимое которых можно обойти
+type IterableOrderStorageInterface interface {
+	OrderStorageInterface
+	// Range вызывает fn для каждого заказа, пока fn возвращает true
+	Range(fn func(order *domain.Order) bool)
 }
@@ -0,0 +1,27 @@
+package protocols
+
+// IdempotencyRecord — сохранённый ответ на запрос с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	// Fingerprint — хеш тела исходного запроса, чтобы отличать повтор от переиспользования ключа
+	Fingerprint string
+	// StatusCode равен нулю, пока исходный запрос ещё выполняется
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+// InFlight сообщает, что ответ на исходный запрос ещё не готов
+func (r *IdempotencyRecord) InFlight() bool {
+	return r.StatusCode == 0
+}
+
+type IdempotencyStorageInterface interface {
+	// Reserve атомарно занимает ключ под запрос с данным отпечатком тела.
+	// Если ключ уже занят, возвращает существующую запись (возможно, ещё выполняющуюся) и false.
+	Reserve(key, fingerprint string) (*IdempotencyRecord, bool)
+	// Save заменяет резерв готовым ответом
+	Save(key string, record *IdempotencyRecord)
+	// Release снимает резерв, чтобы запрос можно было повторить
+	Release(key string)
+}
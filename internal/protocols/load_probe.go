@@ -0,0 +1,6 @@
+package protocols
+
+type LoadProbeInterface interface {
+	// Saturated сообщает, что ресурс исчерпан и новые запросы лучше отклонить сразу
+	Saturated() bool
+}
@@ -0,0 +1,13 @@
+package protocols
+
+import "time"
+
+// NotFoundCacheInterface — кеш отрицательных результатов поиска заказа
+type NotFoundCacheInterface interface {
+	IsMissing(orderUID string) bool
+	// MarkMissing запоминает отсутствие заказа, обнаруженное запросом, начатым в observedAt.
+	// Если заказ был сохранён после observedAt, отметка игнорируется.
+	MarkMissing(orderUID string, observedAt time.Time)
+	// Forget снимает отметку сразу после сохранения заказа
+	Forget(orderUID string)
+}
@@ -0,0 +1,13 @@
+package protocols
+
+// RateLimit — параметры token bucket: RPS токенов в секунду, не более Burst подряд. Нулевой RPS отключает лимит.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// RouteLimits — лимиты маршрута по IP клиента и по аутентифицированному клиенту (API-ключ или subject токена)
+type RouteLimits struct {
+	PerIP  RateLimit
+	PerKey RateLimit
+}
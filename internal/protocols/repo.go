@@ -2,26 +2,62 @@ package protocols
 
 import (
 	"context"
+	"time"
 	"web_service/internal/domain"
 )
 
 type OrderRepoInterface interface {
 	GetById(ctx context.Context, orderUid string) (*domain.Order, error)
 	GetAllOrderUIDs(ctx context.Context) ([]string, error)
+	// GetOrderUIDsUpdatedAfter возвращает заказы, созданные или изменённые после since
+	GetOrderUIDsUpdatedAfter(ctx context.Context, since time.Time) ([]string, error)
 	Save(ctx context.Context, order *domain.Order) error
+	// UpdateVersion переводит заказ с версии version на следующую и обновляет updated_at. Если версия уже
+	// другая — domain.RetryableError (заказ изменили параллельно, транзакцию стоит повторить),
+	// если заказа нет — domain.NotFoundError.
+	UpdateVersion(ctx context.Context, orderUid string, version int) error
 }
 
 type DeliveryRepoInterface interface {
 	GetByOrderId(ctx context.Context, orderUid string) (*domain.Delivery, error)
 	Save(ctx context.Context, delivery *domain.Delivery) error
+	// Update перезаписывает доставку заказа delivery.OrderUID; если её нет — domain.NotFoundError
+	Update(ctx context.Context, delivery *domain.Delivery) error
 }
 
+// PaymentRepoInterface хранит счёт заказа — по одному на заказ, без операций по оплате
 type PaymentRepoInterface interface {
-	GetByTransactionId(ctx context.Context, transaction string) (*domain.Payment, error)
+	GetByOrderId(ctx context.Context, orderUid string) (*domain.Payment, error)
 	Save(ctx context.Context, payment *domain.Payment) error
+	// Update перезаписывает счёт заказа payment.OrderUID; если его нет — domain.NotFoundError
+	Update(ctx context.Context, payment *domain.Payment) error
+}
+
+type PaymentOperationRepoInterface interface {
+	// GetByOrderId возвращает операции по оплате заказа в порядке проведения
+	GetByOrderId(ctx context.Context, orderUid string) ([]domain.PaymentOperation, error)
+	// Save записывает новую операцию; если у заказа уже есть операция с тем же transaction — domain.ConflictError
+	Save(ctx context.Context, orderUid string, operation *domain.PaymentOperation) error
+	// UpdateStatus меняет статус операции transaction заказа; если её нет — domain.NotFoundError
+	UpdateStatus(ctx context.Context, orderUid, transaction string, status domain.PaymentOperationStatus) error
 }
 
 type ItemRepoInterface interface {
 	GetByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Item, error)
 	Save(ctx context.Context, item *domain.Item) error
+	// SaveAll сохраняет товары за один round-trip; ошибка конкретного товара — *domain.ItemSaveError
+	SaveAll(ctx context.Context, items []domain.Item) error
+	// Delete удаляет товар rid заказа trackNumber; если такого товара нет — domain.NotFoundError
+	Delete(ctx context.Context, trackNumber, rid string) error
+}
+
+type OrderStatusRepoInterface interface {
+	// UpdateStatus меняет статус заказа, только если сейчас он равен from, и обновляет updated_at. Если статус
+	// уже другой — domain.RetryableError (транзакцию стоит повторить с новым статусом), если заказа нет —
+	// domain.NotFoundError.
+	UpdateStatus(ctx context.Context, orderUid string, from, to domain.OrderStatus) error
+	// AddHistory дописывает запись в историю статусов заказа
+	AddHistory(ctx context.Context, orderUid string, change domain.StatusChange) error
+	// GetHistory возвращает историю статусов заказа от старых записей к новым
+	GetHistory(ctx context.Context, orderUid string) ([]domain.StatusChange, error)
 }
@@ -0,0 +1,23 @@
+package protocols
+
+import "time"
+
+// Representation — готовое к отправке закодированное представление заказа
+type Representation struct {
+	Body         []byte
+	ETag         string
+	Encoding     string
+	LastModified time.Time
+	// DeliveryService нужен для проверки доступа без декодирования заказа
+	DeliveryService string
+}
+
+// RepresentationStorageInterface реализуют кеши, способные хранить представления рядом с заказом.
+// Поколение меняется при каждом сохранении заказа, поэтому представление, построенное по
+// устаревшей версии заказа, не попадёт в кеш.
+type RepresentationStorageInterface interface {
+	// GetRepresentation возвращает представление и текущее поколение записи; 0 — заказа в кеше нет
+	GetRepresentation(orderUID, variant string) (*Representation, uint64, bool)
+	// SaveRepresentation сохраняет представление, только если запись всё ещё имеет поколение generation
+	SaveRepresentation(orderUID, variant string, generation uint64, representation *Representation)
+}
@@ -1,7 +1,51 @@
 package protocols
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// IsolationLevel — уровень изоляции транзакции; пустое значение оставляет уровень по умолчанию БД
+type IsolationLevel string
+
+const (
+	IsolationDefault        IsolationLevel = ""
+	IsolationReadCommitted  IsolationLevel = "read committed"
+	IsolationRepeatableRead IsolationLevel = "repeatable read"
+	IsolationSerializable   IsolationLevel = "serializable"
+)
+
+// AccessMode — режим доступа транзакции; пустое значение — чтение и запись
+type AccessMode string
+
+const (
+	AccessDefault   AccessMode = ""
+	AccessReadWrite AccessMode = "read write"
+	AccessReadOnly  AccessMode = "read only"
+)
+
+type TxOptions struct {
+	Isolation  IsolationLevel
+	AccessMode AccessMode
+	// Deferrable имеет смысл только для serializable read only: такая транзакция ждёт снимок,
+	// с которым она не может получить ошибку сериализации
+	Deferrable bool
+	// MaxRetries — сколько раз повторить транзакцию целиком после ошибки сериализации или deadlock
+	MaxRetries int
+	// RetryBackoff — пауза перед первым повтором, дальше она удваивается
+	RetryBackoff time.Duration
+	// RoutingKey — ключ данных транзакции (order_uid). После записи по ключу read-only транзакции
+	// с тем же ключом какое-то время идут в primary, а не в реплику.
+	RoutingKey string
+	// Savepoint — если транзакция уже открыта, выполнить fn в точке сохранения, а не просто в ней
+	Savepoint bool
+}
 
 type TransactionManagerInterface interface {
+	// WithinTransaction выполняет fn в транзакции с настройками по умолчанию
 	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// WithinTransactionOptions выполняет fn в транзакции с заданными настройками. Вложенный вызов
+	// переиспользует внешнюю транзакцию (или открывает в ней savepoint) и не повторяется сам:
+	// ошибку сериализации обрабатывает внешний вызов.
+	WithinTransactionOptions(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
 }
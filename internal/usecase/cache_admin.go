@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"web_service/internal/protocols"
+)
+
+var CacheAdminUnsupportedError = errors.New("cache backend does not support administration")
+var WarmUpInProgressError = errors.New("cache warm-up is already running")
+
+// WarmUpProgress — состояние последнего прогрева кеша, запущенного администратором
+type WarmUpProgress struct {
+	Running    bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Total      int
+	Loaded     int
+	Failed     int
+	Error      string
+}
+
+// CacheAdminUseCase даёт администратору посмотреть и изменить содержимое кеша без перезапуска сервиса
+type CacheAdminUseCase struct {
+	// ctx живёт столько же, сколько сервис: прогрев не должен обрываться вместе с HTTP-запросом
+	ctx      context.Context
+	getOrder *GetOrderUseCase
+	storage  protocols.OrderStorageInterface
+	admin    protocols.CacheAdminInterface
+
+	mu       sync.Mutex
+	progress WarmUpProgress
+}
+
+// NewCacheAdminUseCase создаёт use case; если кеш не реализует CacheAdminInterface,
+// доступны только вытеснение заказа и прогрев
+func NewCacheAdminUseCase(ctx context.Context, getOrder *GetOrderUseCase,
+	storage protocols.OrderStorageInterface) *CacheAdminUseCase {
+	admin, _ := storage.(protocols.CacheAdminInterface)
+	return &CacheAdminUseCase{ctx: ctx, getOrder: getOrder, storage: storage, admin: admin}
+}
+
+func (uc *CacheAdminUseCase) Stats() (protocols.CacheStats, error) {
+	if uc.admin == nil {
+		return protocols.CacheStats{}, CacheAdminUnsupportedError
+	}
+	return uc.admin.Stats(), nil
+}
+
+func (uc *CacheAdminUseCase) Keys(after string, limit int) ([]string, error) {
+	if uc.admin == nil {
+		return nil, CacheAdminUnsupportedError
+	}
+	return uc.admin.Keys(after, limit), nil
+}
+
+func (uc *CacheAdminUseCase) Evict(orderUID string) {
+	uc.storage.Delete(orderUID)
+	log.Printf("Order %s evicted from cache by administrator\n", orderUID)
+}
+
+func (uc *CacheAdminUseCase) Flush() error {
+	if uc.admin == nil {
+		return CacheAdminUnsupportedError
+	}
+	uc.admin.Flush()
+	log.Println("Order cache flushed by administrator")
+	return nil
+}
+
+// StartWarmUp запускает RestoreCache в фоне; одновременно может идти только один прогрев
+func (uc *CacheAdminUseCase) StartWarmUp() (WarmUpProgress, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.progress.Running {
+		return uc.progress, WarmUpInProgressError
+	}
+	uc.progress = WarmUpProgress{Running: true, StartedAt: time.Now()}
+	go uc.warmUp()
+	return uc.progress, nil
+}
+
+func (uc *CacheAdminUseCase) WarmUpProgress() WarmUpProgress {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.progress
+}
+
+func (uc *CacheAdminUseCase) warmUp() {
+	err := uc.getOrder.RestoreCacheWithProgress(uc.ctx, func(total, loaded, failed int) {
+		uc.mu.Lock()
+		uc.progress.Total, uc.progress.Loaded, uc.progress.Failed = total, loaded, failed
+		uc.mu.Unlock()
+	})
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.progress.Running = false
+	uc.progress.FinishedAt = time.Now()
+	if err != nil {
+		uc.progress.Error = err.Error()
+		log.Printf("Cache warm-up failed: %v\n", err)
+	}
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import "fmt"
+
+// SaveCachePolicy определяет, что делать с кешем заказов после успешного сохранения
+type SaveCachePolicy string
+
+const (
+	// SaveCacheWriteThrough кладёт сохранённый заказ в кеш сразу после коммита
+	SaveCacheWriteThrough SaveCachePolicy = "write-through"
+	// SaveCacheInvalidate только удаляет заказ из кеша, он загрузится при первом чтении
+	SaveCacheInvalidate SaveCachePolicy = "invalidate"
+	// SaveCacheNone не трогает кеш
+	SaveCacheNone SaveCachePolicy = "none"
+)
+
+func ParseSaveCachePolicy(value string) (SaveCachePolicy, error) {
+	switch policy := SaveCachePolicy(value); policy {
+	case SaveCacheWriteThrough, SaveCacheInvalidate, SaveCacheNone:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown cache policy %q, expected write-through, invalidate or none", value)
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+type ChangeOrderStatusUseCase struct {
+	orderRepo  protocols.OrderRepoInterface
+	statusRepo protocols.OrderStatusRepoInterface
+	txManager  protocols.TransactionManagerInterface
+	storage    protocols.OrderStorageInterface
+}
+
+func NewChangeOrderStatusUseCase(
+	orderRepo protocols.OrderRepoInterface,
+	statusRepo protocols.OrderStatusRepoInterface,
+	txManager protocols.TransactionManagerInterface,
+	storage protocols.OrderStorageInterface,
+) *ChangeOrderStatusUseCase {
+	return &ChangeOrderStatusUseCase{orderRepo: orderRepo, statusRepo: statusRepo,
+		txManager: txManager, storage: storage}
+}
+
+// ChangeStatus переводит заказ в статус из события и дописывает историю. Повторное событие
+// с текущим статусом заказа ничего не меняет, чтобы повторная доставка сообщения была безопасной.
+// Если статус заказа поменялся параллельно, транзакция повторяется и переход проверяется заново.
+func (uc *ChangeOrderStatusUseCase) ChangeStatus(ctx context.Context, event domain.StatusEvent) error {
+	if event.OrderUID == "" {
+		return fmt.Errorf("%w: order_uid is required", domain.OrderInvalidError)
+	}
+	if !event.Status.Valid() {
+		return fmt.Errorf("%w %q", domain.InvalidStatusError, event.Status)
+	}
+	if event.ChangedAt.IsZero() {
+		event.ChangedAt = time.Now()
+	}
+	// Время в истории храним с точностью БД, чтобы кеш и перечитанный заказ совпадали
+	event.ChangedAt = event.ChangedAt.Truncate(time.Microsecond)
+
+	var applied bool
+	opts := saveTxOptions
+	opts.RoutingKey = event.OrderUID
+	err := uc.txManager.WithinTransactionOptions(ctx, opts, func(ctx context.Context) error {
+		applied = false
+		order, err := uc.orderRepo.GetById(ctx, event.OrderUID)
+		if err != nil {
+			return err
+		}
+		applied, err = applyStatus(ctx, uc.statusRepo, order, event.Status, event.ChangedAt)
+		if err != nil || !applied {
+			return err
+		}
+		return uc.orderRepo.UpdateVersion(ctx, event.OrderUID, order.Version)
+	})
+	if err != nil {
+		log.Printf("Failed to change status of order %s to %s: %v\n", event.OrderUID, event.Status, err)
+		return err
+	}
+	if applied {
+		// В кеше заказ со старым статусом и историей — следующее чтение загрузит новую версию
+		uc.storage.Delete(event.OrderUID)
+	}
+	return nil
+}
+
+// applyStatus переводит заказ в статус to и дописывает историю. Если заказ уже в этом статусе,
+// ничего не меняет и возвращает false. Вызывается в транзакции; версию заказа поднимает вызывающий.
+func applyStatus(ctx context.Context, statusRepo protocols.OrderStatusRepoInterface, order *domain.Order,
+	to domain.OrderStatus, changedAt time.Time) (bool, error) {
+	if order.Status == to {
+		return false, nil
+	}
+	if err := order.Status.CheckTransition(to); err != nil {
+		return false, fmt.Errorf("order %s: %w", order.OrderUID, err)
+	}
+	if err := statusRepo.UpdateStatus(ctx, order.OrderUID, order.Status, to); err != nil {
+		return false, err
+	}
+	change := domain.StatusChange{Status: to, ChangedAt: changedAt}
+	if err := statusRepo.AddHistory(ctx, order.OrderUID, change); err != nil {
+		return false, err
+	}
+	return true, nil
+}
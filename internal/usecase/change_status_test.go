@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOrderStartsInCreatedStatus(t *testing.T) {
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	order.Status = domain.StatusDelivered
+	require.NoError(t, env.save.Save(context.Background(), order))
+
+	got, err := env.get.GetOrderById(context.Background(), order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusCreated, got.Status)
+	require.Len(t, got.StatusHistory, 1)
+	assert.Equal(t, domain.StatusCreated, got.StatusHistory[0].Status)
+}
+
+func TestChangeStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		// path — статусы, через которые заказ проходит до проверяемого события
+		path       []domain.OrderStatus
+		event      domain.OrderStatus
+		wantErr    error
+		wantStatus domain.OrderStatus
+		// wantHistory — ожидаемое число записей в истории после события
+		wantHistory int
+	}{
+		{name: "allowed transition", event: domain.StatusPaid, wantStatus: domain.StatusPaid, wantHistory: 2},
+		{name: "forbidden transition is a conflict", event: domain.StatusShipped,
+			wantErr: domain.InvalidStatusTransitionError, wantStatus: domain.StatusCreated, wantHistory: 1},
+		{name: "terminal status accepts nothing", path: []domain.OrderStatus{domain.StatusCancelled},
+			event: domain.StatusPaid, wantErr: domain.ConflictError, wantStatus: domain.StatusCancelled,
+			wantHistory: 2},
+		{name: "redelivered event is a no-op", path: []domain.OrderStatus{domain.StatusPaid},
+			event: domain.StatusPaid, wantStatus: domain.StatusPaid, wantHistory: 2},
+		{name: "unknown status is invalid", event: "lost", wantErr: domain.OrderInvalidError,
+			wantStatus: domain.StatusCreated, wantHistory: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t, SaveCacheNone, nil)
+			order := newOrder()
+			require.NoError(t, env.save.Save(ctx, order))
+			for _, status := range tt.path {
+				require.NoError(t, env.status.ChangeStatus(ctx, domain.StatusEvent{OrderUID: order.OrderUID,
+					Status: status}))
+			}
+
+			err := env.status.ChangeStatus(ctx, domain.StatusEvent{OrderUID: order.OrderUID, Status: tt.event})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			got, err := env.get.GetOrderById(ctx, order.OrderUID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Len(t, got.StatusHistory, tt.wantHistory)
+		})
+	}
+}
+
+func TestChangeStatusUpdatesModificationTime(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order))
+	before, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+
+	require.NoError(t, env.status.ChangeStatus(ctx, domain.StatusEvent{OrderUID: order.OrderUID,
+		Status: domain.StatusPaid}))
+	after, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.True(t, after.UpdatedAt.After(before.UpdatedAt), "updated_at %s, was %s",
+		after.UpdatedAt, before.UpdatedAt)
+	assert.Equal(t, before.DateCreated, after.DateCreated)
+}
+
+func TestChangeStatusOfUnknownOrderIsNotFound(t *testing.T) {
+	env := newTestEnv(t, SaveCacheNone, nil)
+	err := env.status.ChangeStatus(context.Background(), domain.StatusEvent{OrderUID: "missing",
+		Status: domain.StatusPaid})
+	assert.ErrorIs(t, err, domain.NotFoundError)
+}
+
+func TestChangeStatusInvalidatesCachedOrder(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheWriteThrough, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order))
+	require.True(t, env.inCache(order.OrderUID))
+
+	paidAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	require.NoError(t, env.status.ChangeStatus(ctx, domain.StatusEvent{OrderUID: order.OrderUID,
+		Status: domain.StatusPaid, ChangedAt: paidAt}))
+	assert.False(t, env.inCache(order.OrderUID))
+
+	got, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPaid, got.Status)
+	require.Len(t, got.StatusHistory, 2)
+	assert.True(t, paidAt.Equal(got.StatusHistory[1].ChangedAt))
+}
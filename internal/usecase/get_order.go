@@ -2,87 +2,242 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 	"web_service/internal/domain"
 	"web_service/internal/protocols"
+
+	"golang.org/x/sync/singleflight"
 )
 
+// Размер пачки заказов, которую прогрев кеша сохраняет за одну операцию
+const restoreBatchSize = 500
+
+var loadTxOptions = protocols.TxOptions{
+	Isolation:  protocols.IsolationRepeatableRead,
+	AccessMode: protocols.AccessReadOnly,
+}
+
 type GetOrderUseCase struct {
-	orderRepo    protocols.OrderRepoInterface
-	paymentRepo  protocols.PaymentRepoInterface
-	deliveryRepo protocols.DeliveryRepoInterface
-	itemRepo     protocols.ItemRepoInterface
-	txManager    protocols.TransactionManagerInterface
-	storage      protocols.OrderStorageInterface
+	orderRepo     protocols.OrderRepoInterface
+	paymentRepo   protocols.PaymentRepoInterface
+	operationRepo protocols.PaymentOperationRepoInterface
+	deliveryRepo  protocols.DeliveryRepoInterface
+	itemRepo      protocols.ItemRepoInterface
+	statusRepo    protocols.OrderStatusRepoInterface
+	txManager     protocols.TransactionManagerInterface
+	storage       protocols.OrderStorageInterface
+	notFound      protocols.NotFoundCacheInterface
+	// loadProbe может быть nil; при насыщенной БД промахи кеша сразу получают StorageOverloadedError
+	loadProbe protocols.LoadProbeInterface
+	loads     singleflight.Group
 }
 
 func NewGetOrderUseCase(
 	orderRepo protocols.OrderRepoInterface,
 	paymentRepo protocols.PaymentRepoInterface,
+	operationRepo protocols.PaymentOperationRepoInterface,
 	deliveryRepo protocols.DeliveryRepoInterface,
 	itemRepo protocols.ItemRepoInterface,
+	statusRepo protocols.OrderStatusRepoInterface,
 	txManager protocols.TransactionManagerInterface,
 	storage protocols.OrderStorageInterface,
+	notFound protocols.NotFoundCacheInterface,
+	loadProbe protocols.LoadProbeInterface,
 ) *GetOrderUseCase {
-	return &GetOrderUseCase{orderRepo: orderRepo, paymentRepo: paymentRepo,
-		deliveryRepo: deliveryRepo, itemRepo: itemRepo,
-		txManager: txManager, storage: storage}
+	return &GetOrderUseCase{orderRepo: orderRepo, paymentRepo: paymentRepo, operationRepo: operationRepo,
+		deliveryRepo: deliveryRepo, itemRepo: itemRepo, statusRepo: statusRepo,
+		txManager: txManager, storage: storage, notFound: notFound, loadProbe: loadProbe}
 }
 
-func (uc *GetOrderUseCase) GetOrderById(orderUid string) (*domain.Order, error) {
-	ctx := context.Background()
+func (uc *GetOrderUseCase) GetOrderById(ctx context.Context, orderUid string) (*domain.Order, error) {
+	return uc.GetOrder(ctx, orderUid, domain.AllOrderParts)
+}
+
+// GetOrder возвращает заказ с запрошенными частями. Из кеша всегда отдаётся полный заказ,
+// при промахе из БД читаются только нужные части, а неполный заказ в кеш не попадает.
+// Одновременные промахи по одному заказу разделяют одну загрузку из БД. Перегрузка БД сказывается
+// только на промахах: заказы из кеша отдаются и тогда, когда заняты все соединения пула.
+func (uc *GetOrderUseCase) GetOrder(ctx context.Context, orderUid string, parts domain.OrderParts) (*domain.Order, error) {
 	order, err := uc.storage.Get(orderUid)
 	if err == nil {
 		return order, nil
 	}
-	order, err = uc.orderRepo.GetById(ctx, orderUid)
+	if uc.notFound.IsMissing(orderUid) {
+		return nil, fmt.Errorf("%w: order %s is cached as missing", domain.OrderNotFoundError, orderUid)
+	}
+
+	key := fmt.Sprintf("%s/%d", orderUid, parts)
+	// Загрузка не должна прерываться, если отменён запрос того, кто её начал: результат ждут и другие
+	loadCtx := context.WithoutCancel(ctx)
+	result := uc.loads.DoChan(key, func() (any, error) {
+		if uc.loadProbe != nil && uc.loadProbe.Saturated() {
+			return nil, fmt.Errorf("%w: cannot load order %s", domain.StorageOverloadedError, orderUid)
+		}
+		order, err := uc.loadOrder(loadCtx, orderUid, parts)
+		if err == nil && parts == domain.AllOrderParts {
+			uc.storage.Save(orderUid, order)
+		}
+		return order, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		order := res.Val.(*domain.Order)
+		if res.Shared {
+			// Результат получили несколько вызывающих — каждому своя копия
+			order = order.Clone()
+		}
+		return order, nil
+	}
+}
+
+// loadOrder читает заказ и его части в одной read-only транзакции REPEATABLE READ: все запросы идут через
+// одно соединение и видят один снимок, поэтому параллельная запись не даст собрать заказ из разных версий
+func (uc *GetOrderUseCase) loadOrder(ctx context.Context, orderUid string, parts domain.OrderParts) (*domain.Order, error) {
+	var order *domain.Order
+	opts := loadTxOptions
+	opts.RoutingKey = orderUid
+	err := uc.txManager.WithinTransactionOptions(ctx, opts, func(ctx context.Context) error {
+		var err error
+		order, err = uc.readOrder(ctx, orderUid, parts)
+		return err
+	})
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	delivery, err := uc.deliveryRepo.GetByOrderId(ctx, orderUid)
+	return order, nil
+}
+
+func (uc *GetOrderUseCase) readOrder(ctx context.Context, orderUid string, parts domain.OrderParts) (*domain.Order, error) {
+	startedAt := time.Now()
+	order, err := uc.orderRepo.GetById(ctx, orderUid)
 	if err != nil {
+		if errors.Is(err, domain.OrderNotFoundError) {
+			uc.notFound.MarkMissing(orderUid, startedAt)
+		}
 		log.Println(err)
 		return nil, err
 	}
-	payment, err := uc.paymentRepo.GetByTransactionId(ctx, orderUid)
+	if parts.Has(domain.PartDelivery) {
+		delivery, err := uc.deliveryRepo.GetByOrderId(ctx, orderUid)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		order.Delivery = *delivery
+	}
+	if parts.Has(domain.PartPayment) {
+		if err := uc.readPayment(ctx, order); err != nil {
+			log.Println(err)
+			return nil, err
+		}
+	}
+	if parts.Has(domain.PartItems) {
+		items, err := uc.itemRepo.GetByTrackNumber(ctx, order.TrackNumber)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		order.Items = items
+	}
+	if parts.Has(domain.PartStatusHistory) {
+		history, err := uc.statusRepo.GetHistory(ctx, orderUid)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		order.StatusHistory = history
+	}
+
+	return order, nil
+}
+
+// readPayment загружает счёт заказа и операции по оплате. Заказ без счёта читается без него и без итогов:
+// остальные части заказа от этого не становятся недоступны.
+func (uc *GetOrderUseCase) readPayment(ctx context.Context, order *domain.Order) error {
+	operations, err := uc.operationRepo.GetByOrderId(ctx, order.OrderUID)
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		return err
+	}
+	payment, err := uc.paymentRepo.GetByOrderId(ctx, order.OrderUID)
+	if errors.Is(err, domain.NotFoundError) {
+		log.Printf("Order %s has no payment\n", order.OrderUID)
+		order.Payment = domain.Payment{Operations: operations}
+		return nil
 	}
-	items, err := uc.itemRepo.GetByTrackNumber(ctx, order.TrackNumber)
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		return err
+	}
+	payment.Operations = operations
+	if payment.Totals, err = payment.ComputeTotals(operations); err != nil {
+		log.Printf("Failed to compute payment totals of order %s: %v\n", order.OrderUID, err)
 	}
-	order.Delivery = *delivery
 	order.Payment = *payment
-	order.Items = items
-
-	uc.storage.Save(orderUid, order)
-
-	return order, nil
+	return nil
 }
 
 func (uc *GetOrderUseCase) RestoreCache(ctx context.Context) error {
+	return uc.RestoreCacheWithProgress(ctx, nil)
+}
+
+// RestoreProgressFunc получает общее число заказов и число уже загруженных и не загруженных из них
+type RestoreProgressFunc func(total, loaded, failed int)
+
+// RestoreCacheWithProgress работает как RestoreCache и сообщает о ходе прогрева после каждой пачки
+func (uc *GetOrderUseCase) RestoreCacheWithProgress(ctx context.Context, progress RestoreProgressFunc) error {
 	log.Println("Starting cache restoration from database...")
 	orderUIDs, err := uc.orderRepo.GetAllOrderUIDs(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get order UIDs: %w", err)
 	}
 	log.Printf("Found %d orders in database\n", len(orderUIDs))
+	uc.warmUp(ctx, orderUIDs, progress)
+	return nil
+}
 
-	successCount := 0
+// RestoreCacheSince догружает в кеш только заказы, созданные или изменённые после since,
+// например после загрузки снимка
+func (uc *GetOrderUseCase) RestoreCacheSince(ctx context.Context, since time.Time) error {
+	log.Printf("Starting cache reconciliation for orders updated after %s...\n", since.Format(time.RFC3339))
+	orderUIDs, err := uc.orderRepo.GetOrderUIDsUpdatedAfter(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to get order UIDs: %w", err)
+	}
+	log.Printf("Found %d new or updated orders in database\n", len(orderUIDs))
+	uc.warmUp(ctx, orderUIDs, nil)
+	return nil
+}
+
+func (uc *GetOrderUseCase) warmUp(ctx context.Context, orderUIDs []string, progress RestoreProgressFunc) {
+	if progress == nil {
+		progress = func(int, int, int) {}
+	}
+	successCount, failedCount := 0, 0
+	progress(len(orderUIDs), 0, 0)
+	batch := make([]*domain.Order, 0, restoreBatchSize)
 	for _, orderUID := range orderUIDs {
-		_, err := uc.GetOrderById(orderUID)
+		order, err := uc.loadOrder(ctx, orderUID, domain.AllOrderParts)
 		if err != nil {
 			log.Printf("Failed to load order %s to cache: %v\n", orderUID, err)
+			failedCount++
 			continue
 		}
-		//uc.storage.Save(orderUID, order)
+		batch = append(batch, order)
 		successCount++
+		if len(batch) == restoreBatchSize {
+			uc.storage.SaveAll(batch)
+			batch = make([]*domain.Order, 0, restoreBatchSize)
+			progress(len(orderUIDs), successCount, failedCount)
+		}
 	}
+	uc.storage.SaveAll(batch)
+	progress(len(orderUIDs), successCount, failedCount)
 	log.Printf("Cache restoration completed. Loaded %d/%d orders\n", successCount, len(orderUIDs))
-	return nil
 }
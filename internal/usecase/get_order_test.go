@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		// cached — положить заказ в кеш, saved — в БД
+		cached, saved bool
+		parts         domain.OrderParts
+		wantErr       error
+		wantItems     bool
+		// wantCached — окажется ли заказ в кеше после чтения
+		wantCached bool
+	}{
+		{name: "cache hit does not need the database", cached: true, parts: domain.AllOrderParts,
+			wantItems: true, wantCached: true},
+		{name: "cache miss loads full order and caches it", saved: true, parts: domain.AllOrderParts,
+			wantItems: true, wantCached: true},
+		{name: "partial order is not cached", saved: true, parts: domain.PartPayment},
+		{name: "missing order is not found", parts: domain.AllOrderParts, wantErr: domain.OrderNotFoundError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, SaveCacheNone, nil)
+			order := newOrder()
+			if tt.saved {
+				require.NoError(t, env.save.Save(context.Background(), order.Clone()))
+			}
+			if tt.cached {
+				env.storage.Save(order.OrderUID, order)
+			}
+
+			got, err := env.get.GetOrder(context.Background(), order.OrderUID, tt.parts)
+			assert.Equal(t, tt.wantCached, env.inCache(order.OrderUID))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, order.OrderUID, got.OrderUID)
+			assert.Equal(t, order.Payment.Amount, got.Payment.Amount)
+			if tt.wantItems {
+				assert.Len(t, got.Items, len(order.Items))
+			} else {
+				assert.Empty(t, got.Items)
+			}
+		})
+	}
+}
+
+func TestGetOrderComputesPaymentTotals(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order.Clone()))
+	require.NoError(t, env.commands.Apply(ctx, domain.OrderCommand{Type: domain.CommandRecordPayment,
+		OrderUID: order.OrderUID, Operation: &domain.PaymentOperation{Transaction: "refund-1",
+			Type: domain.PaymentRefund, Status: domain.PaymentSucceeded, Amount: 317, Currency: "USD"}}))
+
+	got, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Len(t, got.Payment.Operations, 2)
+	assert.Equal(t, order.Payment.Transaction, got.Payment.Operations[0].Transaction)
+	assert.Equal(t, &domain.PaymentTotals{Paid: 1917, Refunded: 317, Outstanding: 317}, got.Payment.Totals)
+}
+
+// missingPayments — репозиторий, в котором нет счёта ни у одного заказа
+type missingPayments struct {
+	protocols.PaymentRepoInterface
+}
+
+func (missingPayments) GetByOrderId(_ context.Context, orderUid string) (*domain.Payment, error) {
+	return nil, fmt.Errorf("get payment of order %s: %w", orderUid, domain.NotFoundError)
+}
+
+func TestGetOrderWithoutPayment(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order.Clone()))
+
+	get := NewGetOrderUseCase(env.orders, missingPayments{env.payments}, env.operations, env.deliveries, env.items,
+		env.statuses, env.tx, env.storage, env.notFound, nil)
+	got, err := get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Len(t, got.Items, len(order.Items))
+	assert.Empty(t, got.Payment.Transaction)
+	assert.Nil(t, got.Payment.Totals)
+	assert.Len(t, got.Payment.Operations, 1)
+}
+
+func TestMissingOrderIsRememberedUntilSaved(t *testing.T) {
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+
+	_, err := env.get.GetOrderById(context.Background(), order.OrderUID)
+	assert.ErrorIs(t, err, domain.OrderNotFoundError)
+	assert.True(t, env.notFound.IsMissing(order.OrderUID))
+
+	require.NoError(t, env.save.Save(context.Background(), order.Clone()))
+	got, err := env.get.GetOrderById(context.Background(), order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, order.OrderUID, got.OrderUID)
+}
+
+func TestRestoreCacheLoadsAllOrders(t *testing.T) {
+	env := newTestEnv(t, SaveCacheNone, nil)
+	orders := []*domain.Order{newOrder(), newOrder(), newOrder()}
+	for _, order := range orders {
+		require.NoError(t, env.save.Save(context.Background(), order.Clone()))
+	}
+
+	var total, loaded int
+	require.NoError(t, env.get.RestoreCacheWithProgress(context.Background(), func(t, l, _ int) {
+		total, loaded = t, l
+	}))
+
+	assert.Equal(t, len(orders), total)
+	assert.Equal(t, len(orders), loaded)
+	for _, order := range orders {
+		assert.True(t, env.inCache(order.OrderUID))
+	}
+}
+
+type saturatedProbe struct{}
+
+func (saturatedProbe) Saturated() bool { return true }
+
+func TestSaturatedStorageShedsOnlyCacheMisses(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	cached, missing := newOrder(), newOrder()
+	require.NoError(t, env.save.Save(ctx, missing.Clone()))
+	env.storage.Save(cached.OrderUID, cached)
+
+	get := NewGetOrderUseCase(env.orders, env.payments, env.operations, env.deliveries, env.items,
+		env.statuses, env.tx, env.storage, env.notFound, saturatedProbe{})
+
+	_, err := get.GetOrderById(ctx, cached.OrderUID)
+	assert.NoError(t, err)
+	_, err = get.GetOrderById(ctx, missing.OrderUID)
+	assert.ErrorIs(t, err, domain.StorageOverloadedError)
+	assert.ErrorIs(t, err, domain.RetryableError)
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/infrastructure/cache"
+	"web_service/internal/infrastructure/memory"
+	"web_service/internal/infrastructure/repotest"
+	"web_service/internal/protocols"
+)
+
+// testEnv — use case'ы поверх репозиториев в памяти и локального кеша
+type testEnv struct {
+	orders     *memory.OrderRepo
+	deliveries *memory.DeliveryRepo
+	payments   *memory.PaymentRepo
+	operations *memory.PaymentOperationRepo
+	items      *memory.ItemRepo
+	statuses   *memory.OrderStatusRepo
+	tx         *memory.TransactionManager
+	storage    *cache.LocalOrderStorage
+	notFound   *cache.LocalNotFoundCache
+	get        *GetOrderUseCase
+	save       *SaveOrderUseCase
+	status     *ChangeOrderStatusUseCase
+	commands   *OrderCommandUseCase
+}
+
+// newTestEnv собирает окружение; wrapItems позволяет подменить репозиторий товаров, например чтобы он падал
+func newTestEnv(t *testing.T, policy SaveCachePolicy,
+	wrapItems func(items protocols.ItemRepoInterface) protocols.ItemRepoInterface) *testEnv {
+	t.Helper()
+	store := memory.NewStore()
+	env := &testEnv{
+		orders:     memory.NewOrderRepo(store),
+		deliveries: memory.NewDeliveryRepo(store),
+		payments:   memory.NewPaymentRepo(store),
+		operations: memory.NewPaymentOperationRepo(store),
+		items:      memory.NewItemRepo(store),
+		statuses:   memory.NewOrderStatusRepo(store),
+		storage:    cache.NewLocalOrderStorage(),
+		notFound:   cache.NewLocalNotFoundCache(time.Minute),
+	}
+	var items protocols.ItemRepoInterface = env.items
+	if wrapItems != nil {
+		items = wrapItems(items)
+	}
+	env.tx = memory.NewTransactionManager(store)
+	tx := env.tx
+	env.get = NewGetOrderUseCase(env.orders, env.payments, env.operations, env.deliveries, items, env.statuses, tx,
+		env.storage, env.notFound, nil)
+	env.save = NewSaveOrderUseCase(env.orders, env.payments, env.operations, env.deliveries, items, env.statuses, tx,
+		env.notFound, env.storage, policy)
+	env.status = NewChangeOrderStatusUseCase(env.orders, env.statuses, tx, env.storage)
+	env.commands = NewOrderCommandUseCase(env.orders, env.payments, env.operations, env.deliveries, items,
+		env.statuses, tx, env.storage)
+	return env
+}
+
+func newOrder() *domain.Order {
+	return repotest.NewOrder(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC))
+}
+
+// inDatabase сообщает, сохранён ли заказ в репозитории, минуя кеш
+func (env *testEnv) inDatabase(t *testing.T, orderUID string) bool {
+	t.Helper()
+	_, err := env.orders.GetById(context.Background(), orderUID)
+	return err == nil
+}
+
+func (env *testEnv) inCache(orderUID string) bool {
+	_, err := env.storage.Get(orderUID)
+	return err == nil
+}
+
+// failingItems падает на первых failures вызовах SaveAll, остальные передаёт дальше
+type failingItems struct {
+	protocols.ItemRepoInterface
+	failures int
+	err      error
+	calls    int
+}
+
+func (r *failingItems) SaveAll(ctx context.Context, items []domain.Item) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return r.err
+	}
+	return r.ItemRepoInterface.SaveAll(ctx, items)
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+)
+
+type OrderCommandUseCase struct {
+	orderRepo     protocols.OrderRepoInterface
+	paymentRepo   protocols.PaymentRepoInterface
+	operationRepo protocols.PaymentOperationRepoInterface
+	deliveryRepo  protocols.DeliveryRepoInterface
+	itemRepo      protocols.ItemRepoInterface
+	statusRepo    protocols.OrderStatusRepoInterface
+	txManager     protocols.TransactionManagerInterface
+	storage       protocols.OrderStorageInterface
+}
+
+func NewOrderCommandUseCase(
+	orderRepo protocols.OrderRepoInterface,
+	paymentRepo protocols.PaymentRepoInterface,
+	operationRepo protocols.PaymentOperationRepoInterface,
+	deliveryRepo protocols.DeliveryRepoInterface,
+	itemRepo protocols.ItemRepoInterface,
+	statusRepo protocols.OrderStatusRepoInterface,
+	txManager protocols.TransactionManagerInterface,
+	storage protocols.OrderStorageInterface,
+) *OrderCommandUseCase {
+	return &OrderCommandUseCase{orderRepo: orderRepo, paymentRepo: paymentRepo, operationRepo: operationRepo,
+		deliveryRepo: deliveryRepo, itemRepo: itemRepo, statusRepo: statusRepo,
+		txManager: txManager, storage: storage}
+}
+
+// Apply применяет команду к заказу в одной транзакции. Оптимистичная блокировка: в конце версия заказа
+// поднимается compare-and-set'ом, и если заказ успели изменить параллельно, транзакция повторяется
+// с новой версией. Если отправитель указал expected_version, а заказ уже другой версии, —
+// domain.OrderVersionMismatchError без повторов.
+func (uc *OrderCommandUseCase) Apply(ctx context.Context, command domain.OrderCommand) error {
+	if command.IssuedAt.IsZero() {
+		command.IssuedAt = time.Now()
+	}
+	// Время команды попадает в историю статусов и операции, поэтому сразу приводим его к точности БД
+	command.IssuedAt = command.IssuedAt.Truncate(time.Microsecond)
+	// Время операции по умолчанию — время команды; точность — как у БД
+	if command.Operation != nil {
+		operation := *command.Operation
+		if operation.PerformedAt.IsZero() {
+			operation.PerformedAt = command.IssuedAt
+		}
+		operation.PerformedAt = operation.PerformedAt.Truncate(time.Microsecond)
+		command.Operation = &operation
+	}
+	if err := command.Validate(); err != nil {
+		return err
+	}
+
+	var applied bool
+	opts := saveTxOptions
+	opts.RoutingKey = command.OrderUID
+	err := uc.txManager.WithinTransactionOptions(ctx, opts, func(ctx context.Context) error {
+		applied = false
+		order, err := uc.orderRepo.GetById(ctx, command.OrderUID)
+		if err != nil {
+			return err
+		}
+		if command.ExpectedVersion != 0 && command.ExpectedVersion != order.Version {
+			return fmt.Errorf("%w: order %s has version %d, command expects %d", domain.OrderVersionMismatchError,
+				order.OrderUID, order.Version, command.ExpectedVersion)
+		}
+		applied, err = uc.apply(ctx, order, &command)
+		if err != nil || !applied {
+			return err
+		}
+		return uc.orderRepo.UpdateVersion(ctx, order.OrderUID, order.Version)
+	})
+	if err != nil {
+		log.Printf("Failed to apply %s to order %s: %v\n", command.Type, command.OrderUID, err)
+		return err
+	}
+	if applied {
+		uc.storage.Delete(command.OrderUID)
+	}
+	return nil
+}
+
+// apply выполняет саму команду; false означает, что заказ уже в нужном состоянии
+func (uc *OrderCommandUseCase) apply(ctx context.Context, order *domain.Order,
+	command *domain.OrderCommand) (bool, error) {
+	switch command.Type {
+	case domain.CommandCancelOrder:
+		return applyStatus(ctx, uc.statusRepo, order, domain.StatusCancelled, command.IssuedAt)
+	case domain.CommandUpdateDeliveryAddress:
+		return true, uc.updateDeliveryAddress(ctx, order, command.Address)
+	case domain.CommandAddItem:
+		return true, uc.addItem(ctx, order, *command.Item)
+	case domain.CommandRemoveItem:
+		return true, uc.removeItem(ctx, order, command.Rid)
+	case domain.CommandRecordPayment:
+		return uc.recordPayment(ctx, order, *command.Operation)
+	}
+	return false, fmt.Errorf("%w: unknown command type %q", domain.OrderInvalidError, command.Type)
+}
+
+// updateDeliveryAddress меняет адрес, пока заказ не передан в доставку
+func (uc *OrderCommandUseCase) updateDeliveryAddress(ctx context.Context, order *domain.Order,
+	address *domain.DeliveryAddress) error {
+	if !order.Status.BeforeShipment() {
+		return fmt.Errorf("%w: order %s is %s", domain.OrderNotEditableError, order.OrderUID, order.Status)
+	}
+	delivery, err := uc.deliveryRepo.GetByOrderId(ctx, order.OrderUID)
+	if err != nil {
+		return err
+	}
+	delivery.Zip, delivery.City = address.Zip, address.City
+	delivery.Address, delivery.Region = address.Address, address.Region
+	return uc.deliveryRepo.Update(ctx, delivery)
+}
+
+// addItem добавляет товар в ещё не оплаченный заказ и увеличивает сумму оплаты на его стоимость
+func (uc *OrderCommandUseCase) addItem(ctx context.Context, order *domain.Order, item domain.Item) error {
+	if order.Status != domain.StatusCreated {
+		return fmt.Errorf("%w: order %s is %s", domain.OrderNotEditableError, order.OrderUID, order.Status)
+	}
+	if item.TrackNumber == "" {
+		item.TrackNumber = order.TrackNumber
+	}
+	if item.TrackNumber != order.TrackNumber {
+		return &domain.ValidationError{Violations: []domain.Violation{
+			{Field: "item.track_number", Message: "must match order track_number"},
+		}}
+	}
+	if err := uc.itemRepo.Save(ctx, &item); err != nil {
+		return err
+	}
+	return uc.adjustGoodsTotal(ctx, order.OrderUID, item.TotalPrice)
+}
+
+// removeItem убирает товар из ещё не оплаченного заказа; последний товар удалить нельзя
+func (uc *OrderCommandUseCase) removeItem(ctx context.Context, order *domain.Order, rid string) error {
+	if order.Status != domain.StatusCreated {
+		return fmt.Errorf("%w: order %s is %s", domain.OrderNotEditableError, order.OrderUID, order.Status)
+	}
+	items, err := uc.itemRepo.GetByTrackNumber(ctx, order.TrackNumber)
+	if err != nil {
+		return err
+	}
+	var removed *domain.Item
+	for i := range items {
+		if items[i].Rid == rid {
+			removed = &items[i]
+			break
+		}
+	}
+	if removed == nil {
+		return fmt.Errorf("%w: order %s has no item %s", domain.NotFoundError, order.OrderUID, rid)
+	}
+	if len(items) == 1 {
+		return fmt.Errorf("%w: cannot remove the last item %s of order %s", domain.OrderNotEditableError,
+			rid, order.OrderUID)
+	}
+	if err := uc.itemRepo.Delete(ctx, order.TrackNumber, rid); err != nil {
+		return err
+	}
+	return uc.adjustGoodsTotal(ctx, order.OrderUID, -removed.TotalPrice)
+}
+
+// recordPayment записывает новую операцию по оплате или новый статус уже записанной. Повтор операции
+// с тем же статусом ничего не меняет; возвраты не могут превысить успешные списания.
+func (uc *OrderCommandUseCase) recordPayment(ctx context.Context, order *domain.Order,
+	operation domain.PaymentOperation) (bool, error) {
+	payment, err := uc.paymentRepo.GetByOrderId(ctx, order.OrderUID)
+	if err != nil {
+		return false, err
+	}
+	if operation.Currency != payment.Currency {
+		return false, &domain.ValidationError{Violations: []domain.Violation{
+			{Field: "operation.currency", Message: "must match payment currency"},
+		}}
+	}
+	operations, err := uc.operationRepo.GetByOrderId(ctx, order.OrderUID)
+	if err != nil {
+		return false, err
+	}
+	recorded := slices.IndexFunc(operations, func(op domain.PaymentOperation) bool {
+		return op.Transaction == operation.Transaction
+	})
+	if recorded >= 0 {
+		existing := &operations[recorded]
+		if existing.Type != operation.Type || existing.Amount != operation.Amount {
+			return false, fmt.Errorf("%w: operation %s of order %s is a %s of %d", domain.PaymentOperationConflictError,
+				existing.Transaction, order.OrderUID, existing.Type, existing.Amount)
+		}
+		if existing.Status == operation.Status {
+			return false, nil
+		}
+		if err := existing.CheckStatusChange(operation.Status); err != nil {
+			return false, err
+		}
+		existing.Status = operation.Status
+	} else {
+		operations = append(operations, operation)
+	}
+
+	totals, err := payment.ComputeTotals(operations)
+	if err != nil {
+		return false, err
+	}
+	if totals.Refunded > totals.Paid {
+		return false, fmt.Errorf("%w: order %s would have %s refunded of %s paid", domain.RefundExceedsPaidError,
+			order.OrderUID, payment.Money(totals.Refunded), payment.Money(totals.Paid))
+	}
+	if recorded >= 0 {
+		return true, uc.operationRepo.UpdateStatus(ctx, order.OrderUID, operation.Transaction, operation.Status)
+	}
+	return true, uc.operationRepo.Save(ctx, order.OrderUID, &operation)
+}
+
+// adjustGoodsTotal меняет стоимость товаров и итоговую сумму оплаты заказа на delta
+func (uc *OrderCommandUseCase) adjustGoodsTotal(ctx context.Context, orderUID string, delta domain.MinorUnits) error {
+	payment, err := uc.paymentRepo.GetByOrderId(ctx, orderUID)
+	if errors.Is(err, domain.NotFoundError) {
+		return fmt.Errorf("%w: order %s has no payment to recalculate", domain.OrderNotEditableError, orderUID)
+	}
+	if err != nil {
+		return err
+	}
+	goodsTotal, err := payment.Money(payment.GoodsTotal).Add(payment.Money(delta))
+	if err != nil {
+		return err
+	}
+	amount, err := payment.Money(payment.Amount).Add(payment.Money(delta))
+	if err != nil {
+		return err
+	}
+	payment.GoodsTotal, payment.Amount = goodsTotal.Amount, amount.Amount
+	return uc.paymentRepo.Update(ctx, payment)
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"testing"
+	"web_service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCommand(t *testing.T) {
+	newItem := domain.Item{Rid: "added-item", ChrtID: 1, Price: 200, Name: "Lipstick", Size: "0",
+		TotalPrice: 200, NmID: 1, Brand: "Vivienne Sabo"}
+	address := &domain.DeliveryAddress{Zip: "3100001", City: "Haifa", Address: "Herzl 1", Region: "Haifa"}
+	hugeItem := newItem
+	hugeItem.Rid, hugeItem.TotalPrice = "huge-item", math.MaxInt64
+	refund := func(amount domain.MinorUnits, currency domain.Currency) *domain.PaymentOperation {
+		return &domain.PaymentOperation{Transaction: "refund-1", Type: domain.PaymentRefund,
+			Status: domain.PaymentSucceeded, Amount: amount, Currency: currency, Provider: "wbpay"}
+	}
+
+	tests := []struct {
+		name string
+		// status — статус, в который заказ переводится перед командой
+		status  domain.OrderStatus
+		command domain.OrderCommand
+		wantErr error
+		check   func(t *testing.T, before, after *domain.Order)
+	}{
+		{name: "cancel", command: domain.OrderCommand{Type: domain.CommandCancelOrder},
+			check: func(t *testing.T, before, after *domain.Order) {
+				assert.Equal(t, domain.StatusCancelled, after.Status)
+				assert.Len(t, after.StatusHistory, 2)
+			}},
+		{name: "stale expected version is rejected", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandCancelOrder, ExpectedVersion: 1},
+			wantErr: domain.OrderVersionMismatchError},
+		{name: "update delivery address", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandUpdateDeliveryAddress, Address: address},
+			check: func(t *testing.T, before, after *domain.Order) {
+				assert.Equal(t, "Haifa", after.Delivery.City)
+				assert.Equal(t, "Herzl 1", after.Delivery.Address)
+				assert.Equal(t, before.Delivery.Phone, after.Delivery.Phone)
+			}},
+		{name: "add item updates totals", command: domain.OrderCommand{Type: domain.CommandAddItem, Item: &newItem},
+			check: func(t *testing.T, before, after *domain.Order) {
+				assert.Len(t, after.Items, len(before.Items)+1)
+				assert.Equal(t, before.Payment.GoodsTotal+200, after.Payment.GoodsTotal)
+				assert.Equal(t, before.Payment.Amount+200, after.Payment.Amount)
+			}},
+		{name: "add item overflowing totals is invalid",
+			command: domain.OrderCommand{Type: domain.CommandAddItem, Item: &hugeItem},
+			wantErr: domain.OrderInvalidError},
+		{name: "paid order is not editable", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandAddItem, Item: &newItem},
+			wantErr: domain.OrderNotEditableError},
+		{name: "remove unknown item", command: domain.OrderCommand{Type: domain.CommandRemoveItem, Rid: "missing"},
+			wantErr: domain.NotFoundError},
+		{name: "record refund", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandRecordPayment, Operation: refund(317, "USD")},
+			check: func(t *testing.T, before, after *domain.Order) {
+				assert.Len(t, after.Payment.Operations, 2)
+				assert.Equal(t, before.Payment.Totals.Paid, after.Payment.Totals.Paid)
+				assert.Equal(t, domain.MinorUnits(317), after.Payment.Totals.Refunded)
+				assert.Equal(t, domain.MinorUnits(317), after.Payment.Totals.Outstanding)
+			}},
+		{name: "refund above paid amount", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandRecordPayment, Operation: refund(2000, "USD")},
+			wantErr: domain.RefundExceedsPaidError},
+		{name: "operation in another currency", status: domain.StatusPaid,
+			command: domain.OrderCommand{Type: domain.CommandRecordPayment, Operation: refund(317, "EUR")},
+			wantErr: domain.OrderInvalidError},
+		{name: "unknown command is invalid", command: domain.OrderCommand{Type: "rename_order"},
+			wantErr: domain.OrderInvalidError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t, SaveCacheNone, nil)
+			order := newOrder()
+			require.NoError(t, env.save.Save(ctx, order))
+			if tt.status != "" {
+				require.NoError(t, env.status.ChangeStatus(ctx, domain.StatusEvent{OrderUID: order.OrderUID,
+					Status: tt.status}))
+			}
+			before, err := env.get.GetOrderById(ctx, order.OrderUID)
+			require.NoError(t, err)
+
+			tt.command.OrderUID = order.OrderUID
+			err = env.commands.Apply(ctx, tt.command)
+
+			after, getErr := env.get.GetOrderById(ctx, order.OrderUID)
+			require.NoError(t, getErr)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, before, after, "failed command must not change the order")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, before.Version+1, after.Version)
+			tt.check(t, before, after)
+		})
+	}
+}
+
+func TestRemoveItemKeepsLastItem(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order))
+
+	remove := func(rid string) error {
+		return env.commands.Apply(ctx, domain.OrderCommand{Type: domain.CommandRemoveItem, OrderUID: order.OrderUID,
+			Rid: rid})
+	}
+	require.NoError(t, remove(order.Items[0].Rid))
+	assert.ErrorIs(t, remove(order.Items[1].Rid), domain.ConflictError)
+
+	got, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Len(t, got.Items, 1)
+	assert.Equal(t, order.Payment.GoodsTotal-order.Items[0].TotalPrice, got.Payment.GoodsTotal)
+}
+
+func TestCommandForUnknownOrderIsNotFound(t *testing.T) {
+	env := newTestEnv(t, SaveCacheNone, nil)
+	err := env.commands.Apply(context.Background(), domain.OrderCommand{Type: domain.CommandCancelOrder,
+		OrderUID: "missing"})
+	assert.ErrorIs(t, err, domain.NotFoundError)
+}
+
+func TestCommandInvalidatesCachedOrder(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheWriteThrough, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order))
+	require.True(t, env.inCache(order.OrderUID))
+
+	require.NoError(t, env.commands.Apply(ctx, domain.OrderCommand{Type: domain.CommandCancelOrder,
+		OrderUID: order.OrderUID, ExpectedVersion: 1}))
+	assert.False(t, env.inCache(order.OrderUID))
+}
+
+func TestRecordPaymentStatusChanges(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, SaveCacheNone, nil)
+	order := newOrder()
+	require.NoError(t, env.save.Save(ctx, order))
+
+	record := func(status domain.PaymentOperationStatus) error {
+		return env.commands.Apply(ctx, domain.OrderCommand{Type: domain.CommandRecordPayment,
+			OrderUID: order.OrderUID, Operation: &domain.PaymentOperation{Transaction: "charge-2",
+				Type: domain.PaymentCharge, Status: status, Amount: 100, Currency: "USD"}})
+	}
+	require.NoError(t, record(domain.PaymentPending))
+	pending, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, order.Payment.Amount, pending.Payment.Totals.Paid, "pending charge is not paid yet")
+
+	// Повторная доставка той же операции ничего не меняет
+	require.NoError(t, record(domain.PaymentPending))
+	repeated, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, pending.Version, repeated.Version)
+
+	require.NoError(t, record(domain.PaymentSucceeded))
+	succeeded, err := env.get.GetOrderById(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, order.Payment.Amount+100, succeeded.Payment.Totals.Paid)
+	assert.Equal(t, domain.MinorUnits(-100), succeeded.Payment.Totals.Outstanding)
+	assert.Equal(t, domain.PaymentSucceeded, succeeded.Payment.Operations[1].Status)
+	assert.False(t, succeeded.Payment.Operations[1].PerformedAt.IsZero())
+
+	assert.ErrorIs(t, record(domain.PaymentFailed), domain.PaymentOperationConflictError)
+
+	// Та же transaction с другой суммой — другая операция, а не повтор
+	err = env.commands.Apply(ctx, domain.OrderCommand{Type: domain.CommandRecordPayment, OrderUID: order.OrderUID,
+		Operation: &domain.PaymentOperation{Transaction: order.Payment.Transaction, Type: domain.PaymentCharge,
+			Status: domain.PaymentSucceeded, Amount: 1, Currency: "USD"}})
+	assert.ErrorIs(t, err, domain.PaymentOperationConflictError)
+}
@@ -2,35 +2,88 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"log"
+	"slices"
+	"time"
 	"web_service/internal/domain"
 	"web_service/internal/protocols"
 )
 
+// Сохранение заказа повторяется целиком, если транзакция упала на deadlock или ошибке сериализации
+var saveTxOptions = protocols.TxOptions{
+	Isolation:    protocols.IsolationReadCommitted,
+	AccessMode:   protocols.AccessReadWrite,
+	MaxRetries:   3,
+	RetryBackoff: 20 * time.Millisecond,
+}
+
 type SaveOrderUseCase struct {
-	orderRepo    protocols.OrderRepoInterface
-	paymentRepo  protocols.PaymentRepoInterface
-	deliveryRepo protocols.DeliveryRepoInterface
-	itemRepo     protocols.ItemRepoInterface
-	txManager    protocols.TransactionManagerInterface
+	orderRepo     protocols.OrderRepoInterface
+	paymentRepo   protocols.PaymentRepoInterface
+	operationRepo protocols.PaymentOperationRepoInterface
+	deliveryRepo  protocols.DeliveryRepoInterface
+	itemRepo      protocols.ItemRepoInterface
+	statusRepo    protocols.OrderStatusRepoInterface
+	txManager     protocols.TransactionManagerInterface
+	notFound      protocols.NotFoundCacheInterface
+	storage       protocols.OrderStorageInterface
+	cachePolicy   SaveCachePolicy
 }
 
 func NewSaveOrderUseCase(
 	orderRepo protocols.OrderRepoInterface,
 	paymentRepo protocols.PaymentRepoInterface,
+	operationRepo protocols.PaymentOperationRepoInterface,
 	deliveryRepo protocols.DeliveryRepoInterface,
 	itemRepo protocols.ItemRepoInterface,
+	statusRepo protocols.OrderStatusRepoInterface,
 	txManager protocols.TransactionManagerInterface,
+	notFound protocols.NotFoundCacheInterface,
+	storage protocols.OrderStorageInterface,
+	cachePolicy SaveCachePolicy,
 ) *SaveOrderUseCase {
-	return &SaveOrderUseCase{orderRepo: orderRepo, paymentRepo: paymentRepo,
-		deliveryRepo: deliveryRepo, itemRepo: itemRepo,
-		txManager: txManager}
+	return &SaveOrderUseCase{orderRepo: orderRepo, paymentRepo: paymentRepo, operationRepo: operationRepo,
+		deliveryRepo: deliveryRepo, itemRepo: itemRepo, statusRepo: statusRepo,
+		txManager: txManager, notFound: notFound,
+		storage: storage, cachePolicy: cachePolicy}
 }
 
-func (uc *SaveOrderUseCase) Save(order *domain.Order) error {
-	ctx := context.Background()
-	err := uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+func (uc *SaveOrderUseCase) Save(ctx context.Context, order *domain.Order) error {
+	if err := order.Validate(); err != nil {
+		return err
+	}
+	// Статус, версию и время изменения ведёт сервис: новый заказ всегда начинает жизненный цикл с created и версии 1
+	// Точность — как у БД: иначе после перечитывания заказа изменились бы ETag и Last-Modified
+	now := time.Now().Truncate(time.Microsecond)
+	order.Status = domain.StatusCreated
+	order.StatusHistory = []domain.StatusChange{{Status: domain.StatusCreated, ChangedAt: now}}
+	order.Version = 1
+	order.UpdatedAt = now
+	if order.Payment.Transaction == "" {
+		order.Payment.Transaction = order.OrderUID
+	}
+	// Операции в заказе упорядочены так же, как их вернёт чтение из БД. БД хранит время с точностью
+	// до микросекунд, поэтому обрезаем его заранее: иначе порядок в кеше мог бы разойтись с ORDER BY performed_at, id
+	operations := slices.Clone(order.Payment.InitialOperations())
+	for i := range operations {
+		operations[i].PerformedAt = operations[i].PerformedAt.Truncate(time.Microsecond)
+	}
+	slices.SortStableFunc(operations, func(a, b domain.PaymentOperation) int {
+		return a.PerformedAt.Compare(b.PerformedAt)
+	})
+	totals, err := order.Payment.ComputeTotals(operations)
+	if err != nil {
+		return err
+	}
+	order.Payment.Operations, order.Payment.Totals = operations, totals
+	opts := saveTxOptions
+	opts.RoutingKey = order.OrderUID
+	err = uc.txManager.WithinTransactionOptions(ctx, opts, func(ctx context.Context) error {
 		existingOrder, err := uc.orderRepo.GetById(ctx, order.OrderUID)
+		if err != nil && !errors.Is(err, domain.NotFoundError) {
+			return err
+		}
 		if existingOrder != nil {
 			log.Printf("order %s already exists: %s", order.OrderUID, err)
 			return domain.OrderAlreadyExistsError
@@ -46,25 +99,47 @@ func (uc *SaveOrderUseCase) Save(order *domain.Order) error {
 			log.Println(err)
 			return err
 		}
-		order.Payment.Transaction = order.OrderUID
+		order.Payment.OrderUID = order.OrderUID
 		err = uc.paymentRepo.Save(ctx, &order.Payment)
 		if err != nil {
 			log.Println(err)
 			return err
 		}
-		for _, item := range order.Items {
-			err = uc.itemRepo.Save(ctx, &item)
-			if err != nil {
+		for i := range operations {
+			if err := uc.operationRepo.Save(ctx, order.OrderUID, &operations[i]); err != nil {
 				log.Println(err)
 				return err
 			}
 		}
+		err = uc.itemRepo.SaveAll(ctx, order.Items)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		err = uc.statusRepo.AddHistory(ctx, order.OrderUID, order.StatusHistory[0])
+		if err != nil {
+			log.Println(err)
+			return err
+		}
 
-		return err
+		return nil
 	})
 	if err != nil {
+		// Транзакция откатилась — кеш не трогаем
 		return err
 	}
+	// Заказ появился в БД — отрицательная отметка в кеше больше не верна
+	uc.notFound.Forget(order.OrderUID)
+	uc.updateCache(order)
 
 	return nil
 }
+
+func (uc *SaveOrderUseCase) updateCache(order *domain.Order) {
+	switch uc.cachePolicy {
+	case SaveCacheWriteThrough:
+		uc.storage.Save(order.OrderUID, order)
+	case SaveCacheInvalidate:
+		uc.storage.Delete(order.OrderUID)
+	}
+}
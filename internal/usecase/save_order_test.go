@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_service/internal/domain"
+	"web_service/internal/protocols"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveOrderCachePolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SaveCachePolicy
+		stale   bool
+		inCache bool
+	}{
+		{name: "write-through caches saved order", policy: SaveCacheWriteThrough, inCache: true},
+		{name: "write-through replaces stale entry", policy: SaveCacheWriteThrough, stale: true, inCache: true},
+		{name: "invalidate drops stale entry", policy: SaveCacheInvalidate, stale: true, inCache: false},
+		{name: "invalidate does not cache", policy: SaveCacheInvalidate, inCache: false},
+		{name: "none leaves stale entry", policy: SaveCacheNone, stale: true, inCache: true},
+		{name: "none does not cache", policy: SaveCacheNone, inCache: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, tt.policy, nil)
+			order := newOrder()
+			if tt.stale {
+				env.storage.Save(order.OrderUID, &domain.Order{OrderUID: order.OrderUID, Locale: "stale"})
+			}
+			env.notFound.MarkMissing(order.OrderUID, time.Now())
+
+			require.NoError(t, env.save.Save(context.Background(), order))
+
+			assert.True(t, env.inDatabase(t, order.OrderUID))
+			assert.False(t, env.notFound.IsMissing(order.OrderUID), "negative cache mark must be forgotten")
+			cached, err := env.storage.Get(order.OrderUID)
+			if !tt.inCache {
+				assert.ErrorIs(t, err, domain.OrderNotFoundError)
+				return
+			}
+			require.NoError(t, err)
+			if tt.policy == SaveCacheWriteThrough {
+				assert.Equal(t, order.Locale, cached.Locale)
+			} else {
+				assert.Equal(t, "stale", cached.Locale)
+			}
+		})
+	}
+}
+
+func TestSaveOrderFailures(t *testing.T) {
+	storageError := errors.New("disk is on fire")
+	tests := []struct {
+		name string
+		// prepare готовит окружение и возвращает заказ, который будет сохранён
+		prepare   func(t *testing.T, env *testEnv) *domain.Order
+		failItems *failingItems
+		wantErr   error
+		// persisted — должен ли заказ оказаться в БД после неудачной попытки
+		persisted bool
+	}{
+		{
+			name: "duplicate order is rejected and keeps first version",
+			prepare: func(t *testing.T, env *testEnv) *domain.Order {
+				order := newOrder()
+				require.NoError(t, env.save.Save(context.Background(), order.Clone()))
+				env.storage.Delete(order.OrderUID)
+				order.Locale = "ru"
+				return order
+			},
+			wantErr:   domain.OrderAlreadyExistsError,
+			persisted: true,
+		},
+		{
+			name: "duplicate track number is a conflict",
+			prepare: func(t *testing.T, env *testEnv) *domain.Order {
+				first := newOrder()
+				require.NoError(t, env.save.Save(context.Background(), first))
+				env.storage.Delete(first.OrderUID)
+				second := newOrder()
+				second.TrackNumber = first.TrackNumber
+				for i := range second.Items {
+					second.Items[i].TrackNumber = first.TrackNumber
+				}
+				return second
+			},
+			wantErr: domain.ConflictError,
+		},
+		{
+			name:    "invalid order never reaches the database",
+			prepare: func(t *testing.T, env *testEnv) *domain.Order { order := newOrder(); order.Items = nil; return order },
+			wantErr: domain.OrderInvalidError,
+		},
+		{
+			name:      "failed item insert rolls back order, delivery and payment",
+			prepare:   func(t *testing.T, env *testEnv) *domain.Order { return newOrder() },
+			failItems: &failingItems{failures: 1, err: storageError},
+			wantErr:   storageError,
+		},
+		{
+			name:      "retryable failures are retried until attempts run out",
+			prepare:   func(t *testing.T, env *testEnv) *domain.Order { return newOrder() },
+			failItems: &failingItems{failures: saveTxOptions.MaxRetries + 1, err: domain.RetryableError},
+			wantErr:   domain.RetryableError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wrap func(protocols.ItemRepoInterface) protocols.ItemRepoInterface
+			if tt.failItems != nil {
+				wrap = func(items protocols.ItemRepoInterface) protocols.ItemRepoInterface {
+					tt.failItems.ItemRepoInterface = items
+					return tt.failItems
+				}
+			}
+			env := newTestEnv(t, SaveCacheWriteThrough, wrap)
+			order := tt.prepare(t, env)
+			env.notFound.MarkMissing(order.OrderUID, time.Now())
+
+			err := env.save.Save(context.Background(), order)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			assert.Equal(t, tt.persisted, env.inDatabase(t, order.OrderUID))
+			assert.False(t, env.inCache(order.OrderUID), "cache must not change after a failed save")
+			assert.True(t, env.notFound.IsMissing(order.OrderUID), "negative cache mark must survive a failed save")
+			if tt.persisted {
+				// Повтор не перезаписал первую версию заказа
+				stored, err := env.orders.GetById(context.Background(), order.OrderUID)
+				require.NoError(t, err)
+				assert.Equal(t, "en", stored.Locale)
+			}
+			if !tt.persisted {
+				_, err := env.deliveries.GetByOrderId(context.Background(), order.OrderUID)
+				assert.ErrorIs(t, err, domain.NotFoundError)
+				_, err = env.payments.GetByOrderId(context.Background(), order.OrderUID)
+				assert.ErrorIs(t, err, domain.NotFoundError)
+				operations, err := env.operations.GetByOrderId(context.Background(), order.OrderUID)
+				require.NoError(t, err)
+				assert.Empty(t, operations)
+			}
+		})
+	}
+}
+
+func TestSaveOrderRetriesTransientFailure(t *testing.T) {
+	flaky := &failingItems{failures: 1, err: domain.RetryableError}
+	env := newTestEnv(t, SaveCacheWriteThrough, func(items protocols.ItemRepoInterface) protocols.ItemRepoInterface {
+		flaky.ItemRepoInterface = items
+		return flaky
+	})
+	order := newOrder()
+
+	require.NoError(t, env.save.Save(context.Background(), order))
+
+	assert.Equal(t, 2, flaky.calls)
+	order, err := env.get.GetOrderById(context.Background(), order.OrderUID)
+	require.NoError(t, err)
+	assert.Len(t, order.Items, len(newOrder().Items))
+}
+
+func TestSaveOrderInsideDefaultTransaction(t *testing.T) {
+	env := newTestEnv(t, SaveCacheWriteThrough, nil)
+	order := newOrder()
+
+	err := env.tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		return env.save.Save(ctx, order)
+	})
+
+	require.NoError(t, err)
+	assert.True(t, env.inDatabase(t, order.OrderUID))
+}
+
+func TestSaveOrderCachesOperationsAtDatabasePrecision(t *testing.T) {
+	env := newTestEnv(t, SaveCacheWriteThrough, nil)
+	order := newOrder()
+	performedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	charge := domain.PaymentOperation{Transaction: "charge", Type: domain.PaymentCharge, Status: domain.PaymentSucceeded,
+		Amount: order.Payment.Amount, Currency: order.Payment.Currency, Provider: "wbpay"}
+	// Обе операции попадают в одну микросекунду: порядок должен определяться порядком вставки, как в БД
+	later, earlier := charge, charge
+	later.Transaction, later.Amount, later.PerformedAt = "later", 1, performedAt.Add(900*time.Nanosecond)
+	earlier.PerformedAt = performedAt.Add(100 * time.Nanosecond)
+	order.Payment.Operations = []domain.PaymentOperation{later, earlier}
+
+	require.NoError(t, env.save.Save(context.Background(), order))
+
+	cached, err := env.storage.Get(order.OrderUID)
+	require.NoError(t, err)
+	require.Len(t, cached.Payment.Operations, 2)
+	assert.Equal(t, "later", cached.Payment.Operations[0].Transaction)
+	for _, op := range cached.Payment.Operations {
+		assert.True(t, op.PerformedAt.Equal(performedAt))
+	}
+}
+
+func TestSaveOrderCachesTimesAtDatabasePrecision(t *testing.T) {
+	env := newTestEnv(t, SaveCacheWriteThrough, nil)
+	order := newOrder()
+
+	require.NoError(t, env.save.Save(context.Background(), order))
+
+	cached, err := env.storage.Get(order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, cached.UpdatedAt, cached.UpdatedAt.Truncate(time.Microsecond))
+	require.Len(t, cached.StatusHistory, 1)
+	assert.Equal(t, cached.StatusHistory[0].ChangedAt, cached.StatusHistory[0].ChangedAt.Truncate(time.Microsecond))
+}
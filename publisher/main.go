@@ -81,7 +81,7 @@ func generateRandomOrder() domain.Order {
 		TrackNumber:       trackNumber,
 		Entry:             "WBIL",
 		Delivery:          generateRandomDelivery(),
-		Payment:           generateRandomPayment(orderUID, now),
+		Payment:           generateRandomPayment(orderUID, now, items),
 		Items:             items,
 		Locale:            generateLocale(),
 		InternalSignature: "",
@@ -106,24 +106,25 @@ func generateRandomDelivery() domain.Delivery {
 	}
 }
 
-func generateRandomPayment(orderUID string, now time.Time) domain.Payment {
-	amount := rand.Intn(20000) + 1000
-	deliveryCost := rand.Intn(5000) + 500
-	goodsTotal := amount - deliveryCost
-	if goodsTotal < 100 {
-		goodsTotal = amount - 500
+// generateRandomPayment считает суммы из товаров, иначе сервис отклонит заказ при валидации
+func generateRandomPayment(orderUID string, now time.Time, items []domain.Item) domain.Payment {
+	var goodsTotal domain.MinorUnits
+	for _, item := range items {
+		goodsTotal += item.TotalPrice
 	}
+	deliveryCost := domain.MinorUnits(rand.Intn(5000) + 500)
+	customFee := domain.MinorUnits(rand.Intn(100))
 	return domain.Payment{
 		Transaction:  orderUID,
 		RequestID:    uuid.New().String(),
 		Currency:     "RUB",
 		Provider:     generatePaymentProvider(),
-		Amount:       amount,
+		Amount:       goodsTotal + deliveryCost + customFee,
 		PaymentDt:    now.Unix() - int64(rand.Intn(3600)),
 		Bank:         generateBank(),
 		DeliveryCost: deliveryCost,
 		GoodsTotal:   goodsTotal,
-		CustomFee:    rand.Intn(100),
+		CustomFee:    customFee,
 	}
 }
 
@@ -134,12 +135,12 @@ func generateRandomItem(trackNumber string) domain.Item {
 	return domain.Item{
 		ChrtID:      rand.Intn(10000000),
 		TrackNumber: trackNumber,
-		Price:       price,
+		Price:       domain.MinorUnits(price),
 		Rid:         uuid.New().String(),
 		Name:        generateProductName(),
 		Sale:        sale,
 		Size:        generateSize(),
-		TotalPrice:  price * quantity * (100 - sale) / 100,
+		TotalPrice:  domain.MinorUnits(price * quantity * (100 - sale) / 100),
 		NmID:        rand.Intn(3000000),
 		Brand:       generateBrand(),
 		Status:      generateItemStatus(),